@@ -47,6 +47,29 @@ fully specified. Upon completion of each task, cue rewrites the instance,
 filling in the completed task, and reevaluates which other tasks can
 now start, and so on until all tasks have completed.
 
+As the tasks of a command are reevaluated after each task completes,
+tasks may be generated by comprehensions over the results of other
+tasks. Such comprehensions must refer to these results by their full
+path. For example, to run a task for each cluster listed by another:
+
+	command deploy: {
+		task list: exec.Run & {
+			cmd:    "kubectl config get-clusters"
+			stdout: string
+		}
+		task: {
+			"deploy-\(c)": exec.Run & {
+				cmd: "kubectl --cluster \(c) apply -f deploy.yaml"
+			} for c in strings.Fields(command.deploy.task.list.stdout)
+		}
+	}
+
+An interrupt or termination signal cancels all running tasks. Commands
+started by exec tasks are given a grace period to terminate before they
+are killed. A second signal terminates cue immediately. Tasks defined in
+the finally section of a command are run after all other tasks have
+completed, even if any of these failed or were canceled.
+
 Commands are defined at the top-level of the configuration:
 
 	command <Name>: { // from tool.Command
@@ -72,6 +95,14 @@ Commands are defined at the top-level of the configuration:
 			// supported fields depend on type
 		}
 
+		// A finally task is run after all other tasks have completed,
+		// regardless of whether they succeeded. Finally tasks are
+		// typically used for cleanup, like removing temporary files or
+		// releasing locks. They may depend on other finally tasks.
+		finally <Name>: { // from "tool".Task
+			// supported fields depend on type
+		}
+
 		VarValue = string | bool | int | float | [...string|int|float]
 
 		// var declares values that can be set by command line flags or
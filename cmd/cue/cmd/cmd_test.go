@@ -32,6 +32,8 @@ func TestCmd(t *testing.T) {
 		"baddisplay",
 		"errcode",
 		"http",
+		"finally",
+		"dynamic",
 	}
 	defer func() {
 		stdout = os.Stdout
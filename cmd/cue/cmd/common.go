@@ -21,9 +21,11 @@ import (
 	"strings"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/build"
 	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/cue/load"
+	"cuelang.org/go/cue/parser"
 	"github.com/spf13/cobra"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
@@ -51,6 +53,26 @@ func exitOnErr(cmd *cobra.Command, err error, fatal bool) {
 		return
 	}
 
+	if r := reports[cmd]; r != nil {
+		r.add(err)
+		if fatal {
+			exit()
+		}
+		return
+	}
+
+	w := &bytes.Buffer{}
+	printError(w, err)
+
+	b := w.Bytes()
+	cmd.OutOrStderr().Write(b)
+	if fatal {
+		exit()
+	}
+}
+
+// printError writes a localized, human-readable representation of err to w.
+func printError(w io.Writer, err error) {
 	// Link x/text as our localizer.
 	p := message.NewPrinter(getLang())
 	format := func(w io.Writer, format string, args ...interface{}) {
@@ -59,18 +81,23 @@ func exitOnErr(cmd *cobra.Command, err error, fatal bool) {
 
 	cwd, _ := os.Getwd()
 
-	w := &bytes.Buffer{}
 	errors.Print(w, err, &errors.Config{
 		Format:  format,
 		Cwd:     cwd,
 		ToSlash: inTest,
 	})
+}
 
-	b := w.Bytes()
-	cmd.OutOrStderr().Write(b)
-	if fatal {
-		exit()
+// parseExprs parses the expressions given by the --expression flag. Errors
+// are reported with positions relative to the flag value.
+func parseExprs(cmd *cobra.Command) []ast.Expr {
+	var exprs []ast.Expr
+	for _, e := range flagExpression.StringArray(cmd) {
+		expr, err := parser.ParseExpr("<expression flag>", e)
+		exitOnErr(cmd, err, true)
+		exprs = append(exprs, expr)
 	}
+	return exprs
 }
 
 func buildFromArgs(cmd *cobra.Command, args []string) []*cue.Instance {
@@ -83,14 +110,29 @@ func buildFromArgs(cmd *cobra.Command, args []string) []*cue.Instance {
 
 func loadFromArgs(cmd *cobra.Command, args []string) []*build.Instance {
 	log.SetOutput(cmd.OutOrStderr())
-	binst := load.Instances(args, nil)
+	binst := load.Instances(args, &load.Config{BuildTags: buildTags(cmd)})
 	if len(binst) == 0 {
 		return nil
 	}
 	return binst
 }
 
+// buildTags returns the build tags given by the --tags flag.
+func buildTags(cmd *cobra.Command) []string {
+	var tags []string
+	for _, s := range flagTags.StringArray(cmd) {
+		for _, t := range strings.Split(s, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tags = append(tags, t)
+			}
+		}
+	}
+	return tags
+}
+
 func buildInstances(cmd *cobra.Command, binst []*build.Instance) []*cue.Instance {
+	exitOnErr(cmd, injectTags(cmd, binst), true)
+
 	instances := cue.Build(binst)
 	for _, inst := range instances {
 		// TODO: consider merging errors of multiple files, but ensure
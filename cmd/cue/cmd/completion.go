@@ -0,0 +1,229 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// completeCmd is the name of the hidden command called by the completion
+// scripts to compute completion candidates.
+const completeCmd = "__complete"
+
+func newCompletionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "completion bash|zsh|fish",
+		Short: "output shell completion code",
+		Long: `completion outputs shell completion code for the given shell.
+
+The completion code completes the built-in commands and their flags
+as well as the user-defined commands found in the tool files of the
+package in the current directory.
+
+To load completions for the current bash session:
+
+	$ source <(cue completion bash)
+
+For zsh, after compinit has been called:
+
+	$ source <(cue completion zsh)
+
+For fish:
+
+	$ cue completion fish | source
+`,
+		ValidArgs: []string{"bash", "zsh", "fish"},
+		Args:      cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			script, ok := completionScripts[args[0]]
+			if !ok {
+				return fmt.Errorf("unsupported shell %q", args[0])
+			}
+			_, err := fmt.Fprint(cmd.OutOrStdout(), script)
+			return err
+		},
+	}
+	return cmd
+}
+
+func newCompleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                completeCmd,
+		Hidden:             true,
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w := cmd.OutOrStdout()
+			for _, c := range complete(cmd.Root(), args) {
+				fmt.Fprintln(w, c)
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+// complete computes the completion candidates for the last of the given
+// arguments, which may be empty. Each candidate is optionally followed by
+// a tab and a description.
+//
+// An empty result indicates that the shell should complete file names.
+func complete(root *cobra.Command, args []string) []string {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	prefix := args[len(args)-1]
+	args = args[:len(args)-1]
+
+	var candidates []string
+	add := func(name, desc string) {
+		if !strings.HasPrefix(name, prefix) {
+			return
+		}
+		if desc = strings.TrimSpace(desc); desc != "" {
+			name += "\t" + strings.SplitN(desc, "\n", 2)[0]
+		}
+		candidates = append(candidates, name)
+	}
+
+	if strings.HasPrefix(prefix, "-") {
+		cmd := root
+		if c, _, err := root.Find(args); err == nil && c != nil {
+			cmd = c
+		}
+		addFlag := func(f *pflag.Flag) {
+			if f.Hidden {
+				return
+			}
+			add("--"+f.Name, f.Usage)
+			if f.Shorthand != "" {
+				add("-"+f.Shorthand, f.Usage)
+			}
+		}
+		cmd.LocalFlags().VisitAll(addFlag)
+		cmd.InheritedFlags().VisitAll(addFlag)
+		return candidates
+	}
+
+	switch {
+	case len(args) == 0:
+		for _, c := range root.Commands() {
+			if c.IsAvailableCommand() {
+				add(c.Name(), c.Short)
+			}
+		}
+		addUserCommands(add)
+
+	case len(args) == 1 && (args[0] == "cmd" || args[0] == "help"):
+		if args[0] == "help" {
+			for _, c := range root.Commands() {
+				if c.IsAvailableCommand() {
+					add(c.Name(), c.Short)
+				}
+			}
+		}
+		addUserCommands(add)
+	}
+	return candidates
+}
+
+// addUserCommands calls add for each of the user-defined commands found in the
+// tool files of the package in the current directory. Errors are ignored.
+func addUserCommands(add func(name, desc string)) {
+	var err error
+	defer recoverError(&err)
+
+	// Use a separate command to discard any errors printed while loading.
+	cmd := &cobra.Command{}
+	cmd.SetOutput(ioutil.Discard)
+
+	tools, err := buildTools(cmd, nil)
+	if err != nil || tools == nil {
+		return
+	}
+	iter, err := tools.Lookup(commandSection).Fields()
+	if err != nil {
+		return
+	}
+	for iter.Next() {
+		add(iter.Label(), commandDescription(iter.Value()))
+	}
+}
+
+// commandDescription returns a one-line description of a user-defined
+// command, consisting of its declared usage and short description.
+func commandDescription(v cue.Value) string {
+	usage := lookupString(v, "usage")
+	short := lookupString(v, "short")
+	switch {
+	case usage == "":
+		return short
+	case short == "":
+		return usage
+	}
+	return usage + ": " + short
+}
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for cue
+
+_cue_complete()
+{
+    local IFS=$'\n'
+    local out
+    out=$(cue ` + completeCmd + ` "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null | cut -f1)
+    COMPREPLY=( $(compgen -W "${out}" -- "${COMP_WORDS[COMP_CWORD]}") )
+}
+
+complete -o default -F _cue_complete cue
+`,
+
+	"zsh": `#compdef cue
+
+# zsh completion for cue
+
+_cue_complete()
+{
+    local -a completions
+    local line
+    while IFS= read -r line; do
+        completions+=("${line/$'\t'/:}")
+    done < <(cue ` + completeCmd + ` "${words[@]:1:$((CURRENT-1))}" 2>/dev/null)
+    if (( ${#completions} == 0 )); then
+        _files
+        return
+    fi
+    _describe 'cue' completions
+}
+
+compdef _cue_complete cue
+`,
+
+	"fish": `# fish completion for cue
+
+function __cue_complete
+    set -l args (commandline -opc)
+    set -e args[1]
+    cue ` + completeCmd + ` $args (commandline -ct) 2>/dev/null
+end
+
+complete -c cue -a '(__cue_complete)'
+`,
+}
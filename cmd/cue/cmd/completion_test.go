@@ -0,0 +1,68 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir("testdata/tasks"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	testCases := []struct {
+		args string
+		want string
+	}{{
+		args: "ex",
+		want: "export\toutput data in a standard format",
+	}, {
+		args: "ru",
+		want: "run;run_list;runRedirect",
+	}, {
+		args: "cmd ru",
+		want: "run;run_list;runRedirect",
+	}, {
+		args: "cmd run ",
+		want: "",
+	}, {
+		args: "eval --ex",
+		want: "--expression\tevaluate this expression only",
+	}, {
+		args: "run --verb",
+		want: "--verbose\tprint information about progress",
+	}, {
+		args: "completion -p",
+		want: "-p\tCUE package to evaluate",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.args, func(t *testing.T) {
+			root := newRootCmd().root
+			args := strings.Split(tc.args, " ")
+			got := strings.Join(complete(root, args), ";")
+			if got != tc.want {
+				t.Errorf("\n got: %q\nwant: %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -26,7 +26,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"os/signal"
 	"sync"
+	"syscall"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/internal"
@@ -36,12 +38,12 @@ import (
 	_ "cuelang.org/go/pkg/tool/file"
 	_ "cuelang.org/go/pkg/tool/http"
 	"github.com/spf13/cobra"
-	"golang.org/x/sync/errgroup"
 )
 
 const (
 	commandSection = "command"
 	taskSection    = "task"
+	finallySection = "finally"
 )
 
 func lookupString(obj cue.Value, key string) string {
@@ -92,9 +94,10 @@ func addCustom(parent *cobra.Command, typ, name string, tools *cue.Instance) (*c
 }
 
 type taskKey struct {
-	typ  string
-	name string
-	task string
+	typ     string
+	name    string
+	section string
+	task    string
 }
 
 func (k taskKey) keyForTask(taskName string) taskKey {
@@ -104,9 +107,11 @@ func (k taskKey) keyForTask(taskName string) taskKey {
 
 func keyForReference(ref []string) (k taskKey) {
 	// command <command> task <task>
-	if len(ref) >= 4 && ref[2] == taskSection {
+	// command <command> finally <task>
+	if len(ref) >= 4 && (ref[2] == taskSection || ref[2] == finallySection) {
 		k.typ = ref[0]
 		k.name = ref[1]
+		k.section = ref[2]
 		k.task = ref[3]
 	}
 	return k
@@ -114,11 +119,11 @@ func keyForReference(ref []string) (k taskKey) {
 
 func (k taskKey) taskPath(task string) []string {
 	k.task = task
-	return []string{k.typ, k.name, taskSection, task}
+	return []string{k.typ, k.name, k.section, task}
 }
 
 func (k *taskKey) lookupTasks(root *cue.Instance) cue.Value {
-	return root.Lookup(k.typ, k.name, taskSection)
+	return root.Lookup(k.typ, k.name, k.section)
 }
 
 func doTasks(cmd *cobra.Command, typ, command string, root *cue.Instance) error {
@@ -130,32 +135,143 @@ func doTasks(cmd *cobra.Command, typ, command string, root *cue.Instance) error
 // executeTasks runs user-defined tasks as part of a user-defined command.
 //
 // All tasks are started at once, but will block until tasks that they depend
-// on will continue.
+// on will continue. Tasks in the finally section are run after all other
+// tasks have completed, regardless of whether these succeeded.
 func executeTasks(typ, command string, root *cue.Instance) (err error) {
-	spec := taskKey{typ, command, ""}
-	tasks := spec.lookupTasks(root)
+	ctx, stop := withSignals(context.Background())
+	defer stop()
+
+	spec := taskKey{typ, command, taskSection, ""}
+	root, err = runTasks(ctx, spec, root)
+
+	// Finally tasks run with a fresh context, as the context for the regular
+	// tasks may have been canceled by a signal.
+	spec.section = finallySection
+	if _, ferr := runTasks(context.Background(), spec, root); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// withSignals returns a context that is canceled upon receiving an interrupt
+// or termination signal. A second signal terminates the process immediately.
+func withSignals(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	c := make(chan os.Signal, 2)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-c:
+			cancel()
+		case <-done:
+			return
+		}
+		select {
+		case <-c:
+			os.Exit(1)
+		case <-done:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(c)
+		close(done)
+		cancel()
+	}
+}
+
+// runTasks runs the tasks of the section of a command indicated by spec.
+// It returns the root instance updated with the results of the tasks.
+//
+// The tasks of a section are reevaluated each time a task completes. This
+// allows tasks to be generated, by means of comprehensions, from the results
+// of tasks that have completed earlier.
+func runTasks(ctx context.Context, spec taskKey, root *cue.Instance) (_ *cue.Instance, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	index := map[taskKey]*task{}
+	queue := []*task{}
+	done := make(chan *task)
+	running := 0
+
+	for {
+		if err == nil {
+			if err = scheduleTasks(spec, root, index, &queue); err != nil {
+				cancel()
+			}
+		}
+		if err == nil {
+			tasks := spec.lookupTasks(root)
+			for _, t := range queue {
+				if t.started || !t.ready() {
+					continue
+				}
+				t.started = true
+				running++
+				obj := tasks.Lookup(t.name)
+				go func(t *task) {
+					t.update, t.err = t.Run(&itask.Context{ctx, stdout, stderr}, obj)
+					done <- t
+				}(t)
+			}
+		}
+		if running == 0 {
+			break
+		}
+
+		t := <-done
+		running--
+		t.finished = true
+		if t.err == nil && t.update != nil {
+			root, t.err = root.Fill(t.update, spec.taskPath(t.name)...)
+		}
+		if t.err != nil {
+			if err == nil {
+				err = t.err
+			}
+			cancel()
+		}
+	}
+	return root, err
+}
+
+// scheduleTasks adds tasks that were not previously known to the queue and
+// recomputes the dependencies of all tasks that have not yet started.
+func scheduleTasks(spec taskKey, root *cue.Instance, index map[taskKey]*task, queue *[]*task) error {
+	tasks := spec.lookupTasks(root)
+	if !tasks.Exists() {
+		return nil
+	}
 
 	// Create task entries from spec.
-	queue := []*task{}
 	iter, err := tasks.Fields()
 	if err != nil {
 		return err
 	}
-	for i := 0; iter.Next(); i++ {
-		t, err := newTask(i, iter.Label(), iter.Value())
+	for iter.Next() {
+		key := spec.keyForTask(iter.Label())
+		if _, ok := index[key]; ok {
+			continue
+		}
+		t, err := newTask(len(*queue), iter.Label(), iter.Value())
 		if err != nil {
 			return err
 		}
-		queue = append(queue, t)
-		index[spec.keyForTask(iter.Label())] = t
+		*queue = append(*queue, t)
+		index[key] = t
 	}
 
 	// Mark dependencies for unresolved nodes.
-	for _, t := range queue {
+	for _, t := range *queue {
+		if t.started {
+			continue
+		}
+		t.dep = map[*task]bool{}
 		tasks.Lookup(t.name).Walk(func(v cue.Value) bool {
-			// if v.IsIncomplete() {
 			for _, r := range v.References() {
 				if dep, ok := index[keyForReference(r)]; ok {
 					v := root.Lookup(r...)
@@ -164,49 +280,14 @@ func executeTasks(typ, command string, root *cue.Instance) (err error) {
 					}
 				}
 			}
-			// }
 			return true
 		}, nil)
 	}
 
-	if isCyclic(queue) {
+	if isCyclic(*queue) {
 		return errors.New("cyclic dependency in tasks") // TODO: better message.
 	}
-
-	ctx := context.Background()
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
-	var m sync.Mutex
-
-	g, ctx := errgroup.WithContext(ctx)
-	for _, t := range queue {
-		t := t
-		g.Go(func() error {
-			for d := range t.dep {
-				<-d.done
-			}
-			defer close(t.done)
-			m.Lock()
-			obj := tasks.Lookup(t.name)
-			m.Unlock()
-			update, err := t.Run(&itask.Context{ctx, stdout, stderr}, obj)
-			if err == nil && update != nil {
-				m.Lock()
-				root, err = root.Fill(update, spec.taskPath(t.name)...)
-
-				if err == nil {
-					tasks = spec.lookupTasks(root)
-				}
-				m.Unlock()
-			}
-			if err != nil {
-				cancel()
-			}
-			return err
-		})
-	}
-	return g.Wait()
+	return nil
 }
 
 func isCyclic(tasks []*task) bool {
@@ -249,8 +330,22 @@ type task struct {
 
 	index int
 	name  string
-	done  chan error
 	dep   map[*task]bool
+
+	started  bool
+	finished bool
+	update   interface{}
+	err      error
+}
+
+// ready reports whether all tasks on which t depends have finished.
+func (t *task) ready() bool {
+	for d := range t.dep {
+		if !d.finished {
+			return false
+		}
+	}
+	return true
 }
 
 var oldKinds = map[string]string{
@@ -289,7 +384,6 @@ func newTask(index int, name string, v cue.Value) (*task, error) {
 		Runner: runner,
 		index:  index,
 		name:   name,
-		done:   make(chan error),
 		dep:    make(map[*task]bool),
 	}, nil
 }
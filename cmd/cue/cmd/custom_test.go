@@ -15,9 +15,18 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	goruntime "runtime"
 	"strconv"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
+
+	"cuelang.org/go/cue"
 )
 
 func TestIsCyclic(t *testing.T) {
@@ -72,3 +81,115 @@ func TestIsCyclic(t *testing.T) {
 		})
 	}
 }
+
+// signalSelf sends a termination signal to the current process.
+func signalSelf(t *testing.T) {
+	t.Helper()
+	if goruntime.GOOS == "windows" {
+		t.Skip("termination signals are not supported on Windows")
+	}
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithSignals(t *testing.T) {
+	ctx, stop := withSignals(context.Background())
+	defer stop()
+
+	signalSelf(t)
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context not canceled after signal")
+	}
+}
+
+func TestWithSignalsExit(t *testing.T) {
+	if os.Getenv("CUE_TEST_SIGNALS") == "1" {
+		ctx, stop := withSignals(context.Background())
+		defer stop()
+		signalSelf(t)
+		<-ctx.Done()
+		signalSelf(t)
+		time.Sleep(10 * time.Second)
+		return
+	}
+	if goruntime.GOOS == "windows" {
+		t.Skip("termination signals are not supported on Windows")
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestWithSignalsExit$")
+	cmd.Env = append(os.Environ(), "CUE_TEST_SIGNALS=1")
+	err := cmd.Run()
+	if e, ok := err.(*exec.ExitError); !ok || e.ExitCode() != 1 {
+		t.Errorf("got %v; want exit status 1", err)
+	}
+}
+
+func TestExecuteTasksSignal(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("termination signals are not supported on Windows")
+	}
+	var r cue.Runtime
+	inst, err := r.Compile("test", `
+command sleep: {
+	task sleep: {
+		kind: "exec"
+		cmd: ["sh", "-c", "echo ready; exec sleep 10"]
+	}
+	finally cleanup: {
+		kind: "print"
+		text: "cleaning up"
+	}
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		stdout = os.Stdout
+		stderr = os.Stderr
+	}()
+	stdout, stderr = pw, pw
+
+	self, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := make(chan []string)
+	go func() {
+		var a []string
+		scanner := bufio.NewScanner(pr)
+		for scanner.Scan() {
+			if a = append(a, scanner.Text()); len(a) == 1 {
+				// The command has started: interrupt it.
+				_ = self.Signal(syscall.SIGTERM)
+			}
+		}
+		lines <- a
+	}()
+
+	start := time.Now()
+	err = executeTasks("command", "sleep", inst)
+	pw.Close()
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("tasks took %v to complete after signal", d)
+	}
+	const wantErr = "signal: terminated"
+	if err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Errorf("got error %v; want %q", err, wantErr)
+	}
+	got := strings.Join(<-lines, "\n")
+	if want := "ready\ncleaning up"; got != want {
+		t.Errorf("got output %q; want %q", got, want)
+	}
+}
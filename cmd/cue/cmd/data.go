@@ -0,0 +1,181 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/token"
+	"github.com/spf13/cobra"
+)
+
+const flagWithContext flagName = "with-context"
+
+// addDataFlags adds the flags for placing data files given on the command
+// line. The --path flag has the shorthand -l, unless the command already
+// uses it, as eval does for --attributes.
+func addDataFlags(cmd *cobra.Command) {
+	short := "l"
+	if cmd.Flags().ShorthandLookup(short) != nil {
+		short = ""
+	}
+	cmd.Flags().StringP(string(flagPath), short, "",
+		"path at which to place data files")
+	cmd.Flags().Bool(string(flagWithContext), false,
+		"evaluate the --path expression within the context of a data file")
+}
+
+// splitDataFiles separates the arguments that refer to data files from
+// those that specify CUE instances.
+func splitDataFiles(args []string) (cueArgs, files []string) {
+	for _, a := range args {
+		if info := getExtInfo(filepath.Ext(a)); info != nil && info.fnStream != nil {
+			files = append(files, a)
+		} else {
+			cueArgs = append(cueArgs, a)
+		}
+	}
+	return cueArgs, files
+}
+
+// readDataFile returns the documents of the given data file.
+func readDataFile(cmd *cobra.Command, filename string) ([]ast.Expr, error) {
+	info := getExtInfo(filepath.Ext(filename))
+	if info == nil || info.fnStream == nil {
+		return nil, fmt.Errorf("unsupported data file %q", filename)
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return info.fnStream(cmd, filename, f)
+}
+
+// A dataPlacer places the documents of data files at the path given by the
+// --path flag.
+type dataPlacer struct {
+	path        []ast.Label
+	withContext bool
+}
+
+func newDataPlacer(cmd *cobra.Command) (*dataPlacer, error) {
+	p := &dataPlacer{withContext: flagWithContext.Bool(cmd)}
+	if s := flagPath.String(cmd); s != "" {
+		path, err := parsePath(s)
+		if err != nil {
+			return nil, err
+		}
+		p.path = path
+	}
+	return p, nil
+}
+
+// place returns expr nested within structs for each of the labels of the
+// path, along with these labels. The document is the index-th of count
+// documents of the given file.
+//
+// With --with-context, the interpolations of the path are evaluated within
+// a struct with the fields filename, index, recordCount, and data, the
+// latter holding the document itself. Otherwise, they are evaluated within
+// the scope of the document.
+func (p *dataPlacer) place(filename string, index, count int, expr ast.Expr) (ast.Expr, []ast.Label, error) {
+	if p.path == nil {
+		return expr, nil, nil
+	}
+	scope := expr
+	if p.withContext {
+		scope = &ast.StructLit{Elts: []ast.Decl{
+			newField("filename", &ast.BasicLit{
+				Kind:  token.STRING,
+				Value: strconv.Quote(filepath.ToSlash(filename)),
+			}),
+			newField("index", newInt(index)),
+			newField("recordCount", newInt(count)),
+			newField("data", expr),
+		}}
+	}
+	labels, err := evalPath(scope, p.path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s[%d]: %v", filename, index, err)
+	}
+	for i := len(labels) - 1; i >= 0; i-- {
+		expr = &ast.StructLit{Elts: []ast.Decl{
+			&ast.Field{Label: labels[i], Value: expr},
+		}}
+	}
+	return expr, labels, nil
+}
+
+func newField(name string, value ast.Expr) *ast.Field {
+	return &ast.Field{Label: ast.NewIdent(name), Value: value}
+}
+
+func newInt(i int) *ast.BasicLit {
+	return &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(i)}
+}
+
+// buildWithData builds the instances for the given arguments, as
+// buildFromArgs, after adding the documents of the data files among the
+// arguments to each of them.
+func buildWithData(cmd *cobra.Command, args []string) []*cue.Instance {
+	args, files := splitDataFiles(args)
+	binst := loadFromArgs(cmd, args)
+	if binst == nil {
+		return nil
+	}
+	if len(files) > 0 {
+		exitOnErr(cmd, addDataFiles(cmd, binst, files), true)
+	}
+	return buildInstances(cmd, binst)
+}
+
+// addDataFiles adds a file to each of the given instances for each of the
+// given data files. The documents of a data file are placed at the path
+// given by the --path flag and are otherwise merged at the top level.
+func addDataFiles(cmd *cobra.Command, binst []*build.Instance, files []string) error {
+	p, err := newDataPlacer(cmd)
+	if err != nil {
+		return err
+	}
+	for _, filename := range files {
+		objs, err := readDataFile(cmd, filename)
+		if err != nil {
+			return err
+		}
+		f := &ast.File{Filename: filename}
+		for i, expr := range objs {
+			expr, _, err := p.place(filename, i, len(objs), expr)
+			if err != nil {
+				return err
+			}
+			obj, ok := expr.(*ast.StructLit)
+			if !ok {
+				return fmt.Errorf("%s: cannot map non-struct to object root", filename)
+			}
+			f.Decls = append(f.Decls, obj.Elts...)
+		}
+		for _, b := range binst {
+			b.Files = append(b.Files, f)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import "testing"
+
+func TestDataFiles(t *testing.T) {
+	const file = "testdata/data/objects.yaml"
+
+	runCommand(t, newRootCmd().root, "eval_data",
+		"eval", "--path", `"\(strings.ToLower(kind))" "\(name)"`, file)
+
+	runCommand(t, newRootCmd().root, "export_data",
+		"export", "-l", `"\(strings.ToLower(kind))" "\(name)"`, file)
+
+	runCommand(t, newRootCmd().root, "eval_context",
+		"eval", "--with-context", "--path", `records "\(filename)" "\(index)"`, file)
+}
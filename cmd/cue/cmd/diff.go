@@ -0,0 +1,124 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kylelemons/godebug/diff"
+)
+
+// diffContext is the number of unchanged lines shown around changes.
+const diffContext = 3
+
+type diffLine struct {
+	op   byte // ' ', '-', or '+'
+	text string
+}
+
+// writeDiff writes a unified diff of the changes required to turn a into b.
+// Nothing is written if a and b are equal.
+func writeDiff(w io.Writer, nameA, nameB string, a, b []byte) {
+	if string(a) == string(b) {
+		return
+	}
+
+	var lines []diffLine
+	for _, c := range diff.DiffChunks(splitLines(a), splitLines(b)) {
+		for _, l := range c.Deleted {
+			lines = append(lines, diffLine{'-', l})
+		}
+		for _, l := range c.Added {
+			lines = append(lines, diffLine{'+', l})
+		}
+		for _, l := range c.Equal {
+			lines = append(lines, diffLine{' ', l})
+		}
+	}
+
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", nameA, nameB)
+
+	lineA, lineB := 1, 1 // line numbers at the start of lines[i]
+	for i := 0; i < len(lines); {
+		// Find the next change.
+		j := i
+		for j < len(lines) && lines[j].op == ' ' {
+			j++
+		}
+		if j == len(lines) {
+			break
+		}
+		start := j - diffContext
+		if start < i {
+			start = i
+		}
+		lineA += start - i
+		lineB += start - i
+
+		// Extend the hunk until there are more than 2*diffContext unchanged
+		// lines after the last change.
+		end := j
+		for k := j; k < len(lines); k++ {
+			if lines[k].op != ' ' {
+				end = k + 1
+			} else if k-end >= 2*diffContext {
+				break
+			}
+		}
+		end += diffContext
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		countA, countB := 0, 0
+		for _, l := range lines[start:end] {
+			if l.op != '+' {
+				countA++
+			}
+			if l.op != '-' {
+				countB++
+			}
+		}
+		fmt.Fprintf(w, "@@ -%s +%s @@\n",
+			hunkRange(lineA, countA), hunkRange(lineB, countB))
+		for _, l := range lines[start:end] {
+			fmt.Fprintf(w, "%c%s\n", l.op, l.text)
+		}
+
+		lineA += countA
+		lineB += countB
+		i = end
+	}
+}
+
+func hunkRange(start, n int) string {
+	switch n {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, n)
+}
+
+func splitLines(b []byte) []string {
+	s := strings.TrimSuffix(string(b), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
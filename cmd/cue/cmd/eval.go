@@ -20,7 +20,6 @@ import (
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/format"
-	"cuelang.org/go/cue/parser"
 	"github.com/spf13/cobra"
 )
 
@@ -36,6 +35,31 @@ Printing is skipped if validation fails.
 The --expression flag is used to evaluate an expression within the
 configuration file, instead of the entire configuration file itself.
 
+Fields with a @tag(key) attribute can be set from the command line with
+the -t key=value flag. The value is interpreted according to the type of
+the field:
+
+  $ cat <<EOF > env.cue
+  env:      *"dev" | "prod" @tag(env)
+  replicas: int @tag(replicas)
+  EOF
+
+  $ cue eval env.cue -t env=prod -t replicas=3
+  env:      "prod"
+  replicas: 3
+
+Arguments with a JSON or YAML extension are taken to be data files, which
+are unified with the configuration. The --path flag places each document
+at the given path, as described in 'cue help vet'. Unlike for other
+commands, it has no shorthand, as -l is short for --attributes. For
+instance, to check a set of Kubernetes objects against a schema keyed by
+kind and name:
+
+  $ cue eval ./schema objects/*.yaml --path '"\(strings.ToLower(kind))" "\(metadata.name)"'
+
+The --errors flag selects the format in which errors are reported, as
+described in 'cue help vet'.
+
 Examples:
 
   $ cat <<EOF > foo.cue
@@ -46,7 +70,7 @@ Examples:
   "a"
   "c"
 `,
-		RunE: runEval,
+		RunE: withErrorReport(runEval),
 	}
 
 	cmd.Flags().StringArrayP(string(flagExpression), "e", nil, "evaluate this expression only")
@@ -66,6 +90,9 @@ Examples:
 	cmd.Flags().BoolP(string(flagAll), "a", false,
 		"show optional and hidden fields")
 
+	addDataFlags(cmd)
+	addErrorsFlag(cmd)
+
 	// TODO: Option to include comments in output.
 	return cmd
 }
@@ -78,16 +105,9 @@ const (
 )
 
 func runEval(cmd *cobra.Command, args []string) error {
-	instances := buildFromArgs(cmd, args)
+	instances := buildWithData(cmd, args)
 
-	var exprs []ast.Expr
-	for _, e := range flagExpression.StringArray(cmd) {
-		expr, err := parser.ParseExpr("<expression flag>", e)
-		if err != nil {
-			return err
-		}
-		exprs = append(exprs, expr)
-	}
+	exprs := parseExprs(cmd)
 
 	w := cmd.OutOrStdout()
 
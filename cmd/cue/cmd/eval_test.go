@@ -26,4 +26,8 @@ func TestEval(t *testing.T) {
 	cmd = newEvalCmd()
 	cmd.ParseFlags([]string{"-c", "-e", "b.a.b", "-e", "b.idx"})
 	runCommand(t, cmd, "eval_expr")
+
+	cmd = newEvalCmd()
+	cmd.ParseFlags([]string{"-c", "--errors", "json"})
+	runCommand(t, cmd, "eval_json")
 }
@@ -20,6 +20,8 @@ import (
 	"io"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/encoding/toml"
+	"cuelang.org/go/encoding/yaml"
 	"github.com/spf13/cobra"
 )
 
@@ -75,6 +77,17 @@ ancestor directories, up to the module root, belonging to the same package.
 If the package is not explicitly defined by the '-p' flag, it must be uniquely
 defined by the files in the current directory.
 
+Arguments with a JSON or YAML extension are taken to be data files, which
+are unified with the configuration before it is exported. The -l and
+--with-context flags place each document at a path computed from the
+document, as described in 'cue help vet'.
+
+The --expression flag selects the values to export instead of the emit
+value. If the flag is given more than once, the values are exported in
+order as a stream: as consecutive JSON values, as YAML documents
+separated by "---", or as lines of text. TOML does not support streams.
+
+	cue export -e deployment.frontend -e service.frontend --out yaml
 
 Formats
 The following formats are recognized:
@@ -82,6 +95,12 @@ The following formats are recognized:
 json    output as JSON
 		Outputs any CUE value.
 
+yaml    output as YAML
+		Outputs any CUE value.
+
+toml    output as TOML
+		The evaluated value must be a struct and may not contain null.
+
 text    output as raw text
         The evaluated value must be of type string.
 `,
@@ -89,29 +108,59 @@ text    output as raw text
 		RunE: runExport,
 	}
 	flagMedia.Add(cmd)
+	cmd.Flags().StringArrayP(string(flagExpression), "e", nil, "export this expression only")
+	addDataFlags(cmd)
 	cmd.Flags().Bool(string(flagEscape), false, "use HTML escaping")
 
 	return cmd
 }
 
 func runExport(cmd *cobra.Command, args []string) error {
-	instances := buildFromArgs(cmd, args)
+	instances := buildWithData(cmd, args)
+	exprs := parseExprs(cmd)
 	w := cmd.OutOrStdout()
 
 	for _, inst := range instances {
-		root := inst.Value()
-		if !root.IsValid() {
-			continue
+		var values []cue.Value
+		if exprs == nil {
+			root := inst.Value()
+			if !root.IsValid() {
+				continue
+			}
+			values = append(values, root)
 		}
-		switch media := flagMedia.String(cmd); media {
-		case "json":
-			err := outputJSON(cmd, w, root)
-			exitIfErr(cmd, inst, err, true)
-		case "text":
-			err := outputText(w, root)
-			exitIfErr(cmd, inst, err, true)
-		default:
-			return fmt.Errorf("export: unknown format %q", media)
+		for _, e := range exprs {
+			v := inst.Eval(e)
+			exitIfErr(cmd, inst, v.Err(), true)
+			values = append(values, v)
+		}
+
+		for i, v := range values {
+			switch media := flagMedia.String(cmd); media {
+			case "json":
+				err := outputJSON(cmd, w, v)
+				exitIfErr(cmd, inst, err, true)
+			case "yaml":
+				if i > 0 {
+					fmt.Fprintln(w, "---")
+				}
+				err := outputYAML(w, v)
+				exitIfErr(cmd, inst, err, true)
+			case "toml":
+				if i > 0 {
+					return fmt.Errorf("export: cannot output multiple values as TOML")
+				}
+				err := outputTOML(w, v)
+				exitIfErr(cmd, inst, err, true)
+			case "text":
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				err := outputText(w, v)
+				exitIfErr(cmd, inst, err, true)
+			default:
+				return fmt.Errorf("export: unknown format %q", media)
+			}
 		}
 	}
 	return nil
@@ -140,3 +189,21 @@ func outputText(w io.Writer, v cue.Value) error {
 	_, err = fmt.Fprint(w, str)
 	return err
 }
+
+func outputYAML(w io.Writer, v cue.Value) error {
+	b, err := yaml.Encode(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func outputTOML(w io.Writer, v cue.Value) error {
+	b, err := toml.Encode(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
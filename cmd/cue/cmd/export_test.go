@@ -19,4 +19,16 @@ import "testing"
 func TestExport(t *testing.T) {
 	runCommand(t, newExportCmd(), "export")
 	runCommand(t, newExportCmd(), "export_err")
+
+	runCommand(t, newRootCmd().root, "export_toml",
+		"export", "--out", "toml")
+}
+
+func TestExportExpressions(t *testing.T) {
+	runCommand(t, newRootCmd().root, "export_expr",
+		"export", "-e", "deployment.web", "-e", "service.web", "--out", "yaml",
+		"-l", `"\(strings.ToLower(kind))" "\(name)"`, "testdata/data/objects.yaml")
+
+	runCommand(t, newRootCmd().root, "export_badexpr",
+		"export", "-e", "deployment.(web)")
 }
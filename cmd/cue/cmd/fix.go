@@ -0,0 +1,383 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/load"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/scanner"
+	"cuelang.org/go/cue/token"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagRule        flagName = "rule"
+	flagRename      flagName = "rename"
+	flagInteractive flagName = "interactive"
+)
+
+func newFixCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fix [packages]",
+		Short: "rewrite CUE files using predefined rules",
+		Long: `fix rewrites the files of the given packages using rewrite rules.
+
+For each file that is changed, fix prints a unified diff of the changes.
+The files are updated in place, unless the --dryrun flag is given. With
+the --interactive flag, fix asks for confirmation before updating a file.
+The --interactive flag has no shorthand: -i is taken by the global --ignore
+flag.
+
+By default all rules are applied. The --rule flag may be used to select
+specific rules. The following rules are supported:
+
+` + fixRuleDocs() + `
+Examples:
+
+	$ cue fix --rule kinds --dryrun ./...
+
+	$ cue fix --rename replicas=replicaCount --interactive
+`,
+		RunE: runFix,
+	}
+
+	cmd.Flags().StringArrayP(string(flagRule), "r", nil,
+		"name of a rule to apply; may be repeated")
+	cmd.Flags().StringArray(string(flagRename), nil,
+		"rename fields: old=new; may be repeated")
+	cmd.Flags().BoolP(string(flagDryrun), "n", false,
+		"only print the changes")
+	cmd.Flags().Bool(string(flagInteractive), false,
+		"ask for confirmation before updating each file")
+
+	return cmd
+}
+
+// A fixRule defines a named rewrite of CUE files.
+type fixRule struct {
+	name string
+	doc  string
+
+	// fix rewrites f in place and reports whether it changed anything.
+	fix func(cfg *fixConfig, f *ast.File) bool
+}
+
+// fixConfig holds the parameters of rules.
+type fixConfig struct {
+	renames map[string]string
+}
+
+var fixRules = []fixRule{{
+	name: "kinds",
+	doc:  "replace deprecated task kinds in tool files with their qualified names",
+	fix:  fixKinds,
+}, {
+	name: "templates",
+	doc:  "replace the identifier of templates that do not refer to it with _",
+	fix:  fixTemplates,
+}, {
+	name: "rename",
+	doc:  "rename the fields given by the --rename flag and references to them",
+	fix:  fixRename,
+}}
+
+func fixRuleDocs() string {
+	buf := &bytes.Buffer{}
+	for _, r := range fixRules {
+		fmt.Fprintf(buf, "	%-10s %s\n", r.name, r.doc)
+	}
+	return buf.String()
+}
+
+// lookupFixRules returns the rules with the given names or all rules if no
+// names are given.
+func lookupFixRules(names []string) ([]fixRule, error) {
+	if len(names) == 0 {
+		return fixRules, nil
+	}
+	rules := []fixRule{}
+outer:
+	for _, name := range names {
+		for _, r := range fixRules {
+			if r.name == name {
+				rules = append(rules, r)
+				continue outer
+			}
+		}
+		return nil, fmt.Errorf("unknown rule %q", name)
+	}
+	return rules, nil
+}
+
+func runFix(cmd *cobra.Command, args []string) error {
+	rules, err := lookupFixRules(flagRule.StringArray(cmd))
+	if err != nil {
+		return err
+	}
+
+	cfg := &fixConfig{renames: map[string]string{}}
+	for _, s := range flagRename.StringArray(cmd) {
+		p := strings.SplitN(s, "=", 2)
+		if len(p) != 2 || !isIdentifier(p[0]) || !isIdentifier(p[1]) {
+			return fmt.Errorf("invalid rename %q: must be of the form old=new", s)
+		}
+		cfg.renames[p[0]] = p[1]
+	}
+
+	var in *bufio.Reader
+	if flagInteractive.Bool(cmd) {
+		r := stdin
+		if r == nil {
+			r = os.Stdin
+		}
+		in = bufio.NewReader(r)
+	}
+
+	w := cmd.OutOrStdout()
+	for _, inst := range load.Instances(args, &load.Config{Tests: true}) {
+		exitIfErr(cmd, nil, inst.Err, true)
+
+		all := []string{}
+		all = append(all, inst.CUEFiles...)
+		all = append(all, inst.ToolCUEFiles...)
+		all = append(all, inst.TestCUEFiles...)
+		for _, path := range all {
+			filename := inst.Abs(path)
+
+			src, err := ioutil.ReadFile(filename)
+			if err != nil {
+				return err
+			}
+			b, err := fixFile(filename, src, cfg, rules)
+			if err != nil {
+				return err
+			}
+			if b == nil {
+				continue
+			}
+
+			writeDiff(w, path, path, src, b)
+
+			if flagDryrun.Bool(cmd) || (in != nil && !confirm(w, in, path)) {
+				continue
+			}
+
+			stat, err := os.Stat(filename)
+			if err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(filename, b, stat.Mode()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fixFile applies the given rules to the file with the given contents. It
+// returns the formatted result or nil if none of the rules applied.
+func fixFile(filename string, src []byte, cfg *fixConfig, rules []fixRule) ([]byte, error) {
+	f, err := parser.ParseFile(filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	changed := false
+	for _, r := range rules {
+		if r.fix(cfg, f) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil, nil
+	}
+	return format.Node(f)
+}
+
+// confirm asks the user whether to apply the changes to the given file.
+func confirm(w io.Writer, in *bufio.Reader, path string) bool {
+	fmt.Fprintf(w, "apply changes to %s? [y/N] ", path)
+	answer, _ := in.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// fixKinds replaces the deprecated kinds of tasks in tool files.
+func fixKinds(cfg *fixConfig, f *ast.File) bool {
+	if !strings.HasSuffix(f.Filename, "_tool.cue") {
+		return false
+	}
+	changed := false
+	ast.Walk(f, func(n ast.Node) bool {
+		field, ok := n.(*ast.Field)
+		if !ok {
+			return true
+		}
+		if name, _ := ast.LabelName(field.Label); name != "kind" {
+			return true
+		}
+		lit, ok := field.Value.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		str, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			return true
+		}
+		if kind, ok := oldKinds[str]; ok {
+			lit.Value = strconv.Quote(kind)
+			changed = true
+		}
+		return true
+	}, nil)
+	return changed
+}
+
+// fixTemplates replaces the identifiers of templates that are not referenced
+// within the template with _.
+func fixTemplates(cfg *fixConfig, f *ast.File) bool {
+	changed := false
+	ast.Walk(f, func(n ast.Node) bool {
+		field, ok := n.(*ast.Field)
+		if !ok {
+			return true
+		}
+		label, ok := field.Label.(*ast.TemplateLabel)
+		if !ok || label.Ident.Name == "_" {
+			return true
+		}
+		used := false
+		ast.Walk(field.Value, func(n ast.Node) bool {
+			if x, ok := n.(*ast.Ident); ok && x.Node == label {
+				used = true
+			}
+			return !used
+		}, nil)
+		if !used {
+			label.Ident.Name = "_"
+			changed = true
+		}
+		return true
+	}, nil)
+	return changed
+}
+
+// fixRename renames fields and references to these fields. As fields are
+// renamed throughout a package, unresolved identifiers and selectors are
+// assumed to refer to renamed fields, unless they refer to an import.
+func fixRename(cfg *fixConfig, f *ast.File) bool {
+	if len(cfg.renames) == 0 {
+		return false
+	}
+	changed := false
+
+	// Values of renamed fields mapped to the new name.
+	renamed := map[ast.Node]string{}
+	ast.Walk(f, func(n ast.Node) bool {
+		field, ok := n.(*ast.Field)
+		if !ok {
+			return true
+		}
+		name, ok := ast.LabelName(field.Label)
+		if !ok {
+			return true
+		}
+		to, ok := cfg.renames[name]
+		if !ok {
+			return true
+		}
+		switch x := field.Label.(type) {
+		case *ast.Ident:
+			x.Name = to
+		case *ast.BasicLit:
+			x.Value = strconv.Quote(to)
+		default:
+			return true
+		}
+		renamed[field.Value] = to
+		changed = true
+		return true
+	}, nil)
+
+	imports := map[string]bool{}
+	for _, spec := range f.Imports {
+		if spec.Name != nil {
+			imports[spec.Name.Name] = true
+		} else if path, err := strconv.Unquote(spec.Path.Value); err == nil {
+			imports[path[strings.LastIndex(path, "/")+1:]] = true
+		}
+	}
+
+	rename := func(x *ast.Ident, to string, ok bool) {
+		if ok && x.Name != to {
+			x.Name = to
+			changed = true
+		}
+	}
+	for _, x := range f.Unresolved {
+		if !imports[x.Name] {
+			to, ok := cfg.renames[x.Name]
+			rename(x, to, ok)
+		}
+	}
+	ast.Walk(f, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.Ident:
+			if x.Node != nil {
+				to, ok := renamed[x.Node]
+				rename(x, to, ok)
+			}
+		case *ast.SelectorExpr:
+			if root, ok := selectorRoot(x).(*ast.Ident); !ok || !imports[root.Name] || root.Node != nil {
+				to, ok := cfg.renames[x.Sel.Name]
+				rename(x.Sel, to, ok)
+			}
+		}
+		return true
+	}, nil)
+	return changed
+}
+
+// selectorRoot returns the leftmost expression of a chain of selectors.
+func selectorRoot(x *ast.SelectorExpr) ast.Expr {
+	for {
+		sel, ok := x.X.(*ast.SelectorExpr)
+		if !ok {
+			return x.X
+		}
+		x = sel
+	}
+}
+
+func isIdentifier(s string) bool {
+	var scan scanner.Scanner
+	scan.Init(token.NewFile("check", -1, len(s)), []byte(s), nil, 0)
+
+	_, tok, lit := scan.Scan()
+	return tok == token.IDENT && lit == s
+}
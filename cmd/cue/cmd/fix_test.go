@@ -0,0 +1,27 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import "testing"
+
+func TestFix(t *testing.T) {
+	cmd := newFixCmd()
+	cmd.ParseFlags([]string{"--dryrun"})
+	runCommand(t, cmd, "fix")
+
+	cmd = newFixCmd()
+	cmd.ParseFlags([]string{"--dryrun", "-r", "rename", "--rename", "replicas=count"})
+	runCommand(t, cmd, "fix_rename")
+}
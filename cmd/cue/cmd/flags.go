@@ -30,6 +30,8 @@ const (
 	flagSimplify flagName = "simplify"
 	flagPackage  flagName = "package"
 	flagDebug    flagName = "debug"
+	flagInject   flagName = "inject"
+	flagTags     flagName = "tags"
 
 	flagExpression flagName = "expression"
 	flagEscape     flagName = "escape"
@@ -42,7 +44,7 @@ const (
 
 var flagMedia = stringFlag{
 	name: "out",
-	text: "output format (json or text)",
+	text: "output format (json, yaml, toml, or text)",
 	def:  "json",
 }
 
@@ -65,6 +67,10 @@ func addGlobalFlags(f *pflag.FlagSet) {
 		"proceed in the presence of errors")
 	f.BoolP(string(flagVerbose), "v", false,
 		"print information about progress")
+	f.StringArrayP(string(flagInject), "t", nil,
+		"set the value of fields with a @tag(key) attribute: key=value")
+	f.StringArray(string(flagTags), nil,
+		"comma-separated list of build tags to consider satisfied")
 }
 
 type flagName string
@@ -15,57 +15,149 @@
 package cmd
 
 import (
+	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 
 	"cuelang.org/go/cue/format"
 	"cuelang.org/go/cue/load"
 	"github.com/spf13/cobra"
 )
 
+const (
+	flagCheck flagName = "check"
+	flagDiff  flagName = "diff"
+)
+
 func newFmtCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "fmt [-s] [packages]",
+		Use:   "fmt [-s] [packages | files | -]",
 		Short: "formats CUE configuration files",
 		Long: `Fmt formats the given files or the files for the given packages in place
+
+Arguments ending in .cue are formatted as individual files, whether or not
+they are part of a package. If the only argument is -, fmt formats its
+standard input and writes the result to standard output.
+
+The --check flag lists the files whose formatting differs from fmt's and
+the -d flag prints the changes as unified diffs. In both cases the files
+are not modified and fmt exits with a non-zero status if any file is not
+formatted.
+
+Examples:
+
+	$ cue fmt --check ./...
+
+	$ cue fmt -d schema.cue
 `,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			for _, inst := range load.Instances(args, nil) {
-				all := []string{}
-				all = append(all, inst.CUEFiles...)
-				all = append(all, inst.ToolCUEFiles...)
-				all = append(all, inst.TestCUEFiles...)
-				for _, path := range all {
-					fullpath := inst.Abs(path)
-
-					stat, err := os.Stat(fullpath)
-					if err != nil {
-						return err
-					}
-
-					b, err := ioutil.ReadFile(fullpath)
-					if err != nil {
-						return err
-					}
-
-					opts := []format.Option{}
-					if flagSimplify.Bool(cmd) {
-						opts = append(opts, format.Simplify())
-					}
-
-					b, err = format.Source(b, opts...)
-					if err != nil {
-						return err
-					}
-
-					err = ioutil.WriteFile(fullpath, b, stat.Mode())
-					if err != nil {
-						return err
-					}
-				}
-			}
-			return nil
-		},
+		RunE: runFmt,
 	}
+
+	cmd.Flags().Bool(string(flagCheck), false,
+		"list files whose formatting differs and do not rewrite them")
+	cmd.Flags().BoolP(string(flagDiff), "d", false,
+		"print diffs instead of rewriting files")
+
 	return cmd
 }
+
+func runFmt(cmd *cobra.Command, args []string) error {
+	opts := []format.Option{}
+	if flagSimplify.Bool(cmd) {
+		opts = append(opts, format.Simplify())
+	}
+
+	if len(args) == 1 && args[0] == "-" {
+		return fmtStdin(cmd, opts)
+	}
+
+	var files, pkgs []string
+	for _, a := range args {
+		if strings.HasSuffix(a, ".cue") {
+			files = append(files, a)
+		} else {
+			pkgs = append(pkgs, a)
+		}
+	}
+	if len(pkgs) > 0 || len(files) == 0 {
+		for _, inst := range load.Instances(pkgs, nil) {
+			exitIfErr(cmd, nil, inst.Err, true)
+			all := []string{}
+			all = append(all, inst.CUEFiles...)
+			all = append(all, inst.ToolCUEFiles...)
+			all = append(all, inst.TestCUEFiles...)
+			for _, path := range all {
+				files = append(files, inst.Abs(path))
+			}
+		}
+	}
+
+	unformatted := false
+	for _, path := range files {
+		ok, err := fmtFile(cmd, path, opts)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			unformatted = true
+		}
+	}
+	if unformatted && (flagCheck.Bool(cmd) || flagDiff.Bool(cmd)) {
+		exit()
+	}
+	return nil
+}
+
+// fmtFile formats the given file. It reports whether the file was already
+// formatted. The file is only rewritten if neither --check nor -d is given.
+func fmtFile(cmd *cobra.Command, path string, opts []format.Option) (ok bool, err error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	b, err := format.Source(src, opts...)
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(src, b) {
+		return true, nil
+	}
+
+	w := cmd.OutOrStdout()
+	name := relFilename(path)
+	switch {
+	case flagDiff.Bool(cmd):
+		writeDiff(w, name, name, src, b)
+	case flagCheck.Bool(cmd):
+		fmt.Fprintln(w, name)
+	default:
+		err = ioutil.WriteFile(path, b, stat.Mode())
+	}
+	return false, err
+}
+
+// fmtStdin formats standard input and writes the result to standard output.
+func fmtStdin(cmd *cobra.Command, opts []format.Option) error {
+	r := stdin
+	if r == nil {
+		r = os.Stdin
+	}
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	b, err := format.Source(src, opts...)
+	if err != nil {
+		return err
+	}
+	_, err = cmd.OutOrStdout().Write(b)
+	return err
+}
@@ -0,0 +1,48 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFmt(t *testing.T) {
+	cmd := newFmtCmd()
+	cmd.ParseFlags([]string{"--check"})
+	runCommand(t, cmd, "fmt_check")
+
+	cmd = newFmtCmd()
+	cmd.ParseFlags([]string{"-d"})
+	runCommand(t, cmd, "fmt_diff")
+}
+
+func TestFmtStdin(t *testing.T) {
+	stdin = strings.NewReader("a: {b:1}\nc:  2\n")
+	defer func() { stdin = nil }()
+
+	cmd := newFmtCmd()
+	out := &bytes.Buffer{}
+	cmd.SetOutput(out)
+	cmd.SetArgs([]string{"-"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	want := "a: {b: 1}\nc: 2\n"
+	if got := out.String(); got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
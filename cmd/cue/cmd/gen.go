@@ -0,0 +1,46 @@
+// Copyright 2018 The CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newGenCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gen <language> [packages]",
+		Short: "generate code for another language from CUE",
+		Long: `Gen generates source code for another language from the definitions
+of CUE packages.
+
+Gen requires an additional language field to determine for which
+language code should be generated. The specifics on how definitions are
+converted vary per language and are documented in the respective
+subcommands.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			msg := "gen must be run as one of its subcommands"
+			if len(args) > 0 {
+				msg += fmt.Sprintf(": unknown subcommand %q", args[0])
+			}
+			return errors.New(msg + "\nRun 'cue help gen' for known subcommands.")
+		},
+	}
+	cmd.AddCommand(newGenGoCmd())
+	return cmd
+}
@@ -0,0 +1,495 @@
+// Copyright 2018 The CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/build"
+	cueformat "cuelang.org/go/cue/format"
+	"github.com/spf13/cobra"
+)
+
+const flagGoPackage flagName = "go-package"
+
+func newGenGoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "go [packages]",
+		Short: "generate Go types from CUE definitions",
+		Long: `go converts CUE definitions into Go types
+
+The command "cue gen go" is the reverse of "cue get go": it converts the
+definitions of a CUE package to Go types that can be used with Go's
+encoding/json package. The types for a package are written to a file
+named cue_gen.go in the package directory, or to the file given by the
+--out flag, where "-" denotes stdout. The name of the Go package defaults
+to the name of the CUE package and can be set with --go-package.
+
+Each top-level field with a name starting with an uppercase letter is
+taken to be a definition and is converted to a Go type of the same name.
+Other top-level fields are considered data and are not converted.
+
+
+Rules of Converting CUE to Go types
+
+	- structs are converted to Go structs. Field names are converted to
+	  CamelCase and the original name is recorded in a "json" tag.
+	- optional fields get the "omitempty" option and are converted to
+	  pointers, unless their type is a slice, map, or interface.
+	- a disjunction with null is converted to a pointer.
+	- a disjunction of string literals is converted to a named string
+	  type, with a constant for each of its values.
+	- a reference to another definition is converted to its Go type.
+	- lists are converted to slices and structs with only a template,
+	  such as "labels <Name>: string", are converted to maps.
+	- int, float, number, string, bytes, and bool are converted to int,
+	  float64, float64, string, []byte, and bool, respectively.
+	- any other value is converted to interface{}.
+	- doc comments are copied to the Go declarations and fields.
+
+Structs and disjunctions nested within a definition are converted to
+named types, where the name is the concatenation of the names of the
+definition and the fields leading to it.
+
+The Go types cannot express all CUE constraints. Therefore, the CUE
+definitions are included in the generated file and each struct type
+derived from a definition gets a Validate method that validates its
+receiver against these definitions using cuelang.org/go/cuego.
+
+For example, the definition
+
+	// Service describes a network service.
+	Service: {
+		name:     =~"^[a-z]+$"
+		port:     int & >=1 & <=65535
+		protocol: *"TCP" | "UDP"
+		labels <_>: string
+	}
+
+is converted to
+
+	// Service describes a network service.
+	type Service struct {
+		Name     string            ` + "`json:\"name\"`" + `
+		Port     int               ` + "`json:\"port\"`" + `
+		Protocol ServiceProtocol   ` + "`json:\"protocol\"`" + `
+		Labels   map[string]string ` + "`json:\"labels\"`" + `
+	}
+
+	type ServiceProtocol string
+
+	const (
+		ServiceProtocolTCP ServiceProtocol = "TCP"
+		ServiceProtocolUDP ServiceProtocol = "UDP"
+	)
+
+	func (x *Service) Validate() error {
+		return cuego.Validate(x)
+	}
+`,
+		RunE: runGenGo,
+	}
+
+	flagOut.Add(cmd)
+	cmd.Flags().String(string(flagGoPackage), "",
+		"name of the generated Go package")
+
+	return cmd
+}
+
+func runGenGo(cmd *cobra.Command, args []string) error {
+	binst := loadFromArgs(cmd, args)
+	if binst == nil {
+		return nil
+	}
+	instances := buildInstances(cmd, binst)
+
+	for i, inst := range instances {
+		pkg := flagGoPackage.String(cmd)
+		if pkg == "" {
+			pkg = binst[i].PkgName
+		}
+		if pkg == "" {
+			return fmt.Errorf("no package name for %s: use --%s", binst[i].Dir, flagGoPackage)
+		}
+
+		b, err := generateGo(pkg, binst[i], inst)
+		if err != nil {
+			return err
+		}
+
+		switch out := flagOut.String(cmd); out {
+		case "-":
+			if _, err := cmd.OutOrStdout().Write(b); err != nil {
+				return err
+			}
+		case "":
+			out = filepath.Join(binst[i].Dir, "cue_gen.go")
+			fallthrough
+		default:
+			if err := ioutil.WriteFile(out, b, 0644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// A goGenerator converts the definitions of a CUE instance to Go types.
+type goGenerator struct {
+	w bytes.Buffer
+
+	types     map[string]string // definition label to Go type name
+	validated []string          // types with a Validate method
+
+	nested []goDecl // nested types of the declaration being generated
+}
+
+// A goDecl is a Go type declaration to generate for a CUE value.
+type goDecl struct {
+	name string
+	v    cue.Value
+	doc  []*ast.CommentGroup
+	top  bool
+}
+
+func generateGo(pkg string, binst *build.Instance, inst *cue.Instance) ([]byte, error) {
+	g := &goGenerator{types: map[string]string{}}
+
+	var decls []goDecl
+	iter, err := inst.Value().Fields()
+	if err != nil {
+		return nil, err
+	}
+	for iter.Next() {
+		label, v := iter.Label(), iter.Value()
+		if !isDefinition(label) {
+			continue
+		}
+		if v.IsConcrete() && v.Kind() != cue.StructKind {
+			continue
+		}
+		g.types[label] = goName(label)
+		decls = append(decls, goDecl{goName(label), v, v.Doc(), true})
+	}
+
+	for _, d := range decls {
+		g.genDecl(d)
+	}
+
+	w := &bytes.Buffer{}
+	fmt.Fprintln(w, "// Code generated by cue gen go. DO NOT EDIT.")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "package %s\n\n", pkg)
+	if len(g.validated) > 0 {
+		fmt.Fprintf(w, "import %q\n\n", "cuelang.org/go/cuego")
+	}
+	w.Write(g.w.Bytes())
+
+	if len(g.validated) > 0 {
+		schema, err := cueSchema(binst)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(w, "func init() {")
+		for _, name := range g.validated {
+			fmt.Fprintf(w, "cuego.MustConstrain(&%s{}, cueSchema+%q)\n", name, "."+name)
+		}
+		fmt.Fprintln(w, "}")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "// cueSchema holds the CUE definitions from which the types in this file")
+		fmt.Fprintln(w, "// were generated.")
+		fmt.Fprintf(w, "const cueSchema = %s\n", goString(schema))
+	}
+
+	b, err := format.Source(w.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("error formatting generated Go code: %v", err)
+	}
+	return b, nil
+}
+
+// isDefinition reports whether a top-level field with the given label
+// should be converted to a Go type.
+func isDefinition(label string) bool {
+	for i, r := range label {
+		switch {
+		case i == 0 && !unicode.IsUpper(r):
+			return false
+		case r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			return false
+		}
+	}
+	return label != ""
+}
+
+// cueSchema returns the top-level declarations of the files of an instance
+// as a single CUE struct.
+func cueSchema(binst *build.Instance) (string, error) {
+	s := &ast.StructLit{}
+	for _, f := range binst.Files {
+		for _, d := range f.Decls {
+			if _, ok := d.(*ast.ImportDecl); !ok {
+				s.Elts = append(s.Elts, d)
+			}
+		}
+	}
+	b, err := cueformat.Node(s)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// goString returns s as a Go string literal, preferring a raw string.
+func goString(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+func (g *goGenerator) genDecl(d goDecl) {
+	saved := g.nested
+	g.nested = nil
+
+	w := &g.w
+	writeDoc(w, d.doc)
+
+	switch values, isEnum := enumValues(d.v); {
+	case isEnum:
+		fmt.Fprintf(w, "type %s string\n\n", d.name)
+		fmt.Fprintln(w, "const (")
+		for _, s := range values {
+			fmt.Fprintf(w, "%s%s %s = %q\n", d.name, goName(s), d.name, s)
+		}
+		fmt.Fprintln(w, ")")
+
+	case isStruct(d.v):
+		fmt.Fprintf(w, "type %s struct {\n", d.name)
+		for i, f := range structFields(d.v) {
+			label, v := f.label, f.v
+			name := goName(label)
+			t := g.typeExpr(d.name+name, v)
+			tag := label
+			if f.optional {
+				t = pointer(t)
+				tag += ",omitempty"
+			}
+			if doc := v.Doc(); len(doc) > 0 {
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				writeDoc(w, doc)
+			}
+			fmt.Fprintf(w, "%s %s `json:%q`\n", name, t, tag)
+		}
+		fmt.Fprintln(w, "}")
+
+		if d.top {
+			fmt.Fprintln(w)
+			fmt.Fprintf(w, "// Validate reports whether x satisfies the CUE definition of %s.\n", d.name)
+			fmt.Fprintf(w, "func (x *%s) Validate() error {\n", d.name)
+			fmt.Fprintln(w, "return cuego.Validate(x)")
+			fmt.Fprintln(w, "}")
+			g.validated = append(g.validated, d.name)
+		}
+
+	default:
+		fmt.Fprintf(w, "type %s %s\n", d.name, g.typeExpr(d.name, d.v))
+	}
+	fmt.Fprintln(w)
+
+	nested := g.nested
+	g.nested = saved
+	for _, d := range nested {
+		g.genDecl(d)
+	}
+}
+
+type structField struct {
+	label    string
+	v        cue.Value
+	optional bool
+}
+
+// structFields returns the fields of the struct v in the order in which they
+// appear in the CUE source. Fields are not always iterated in source order,
+// for instance for the elements of a list.
+func structFields(v cue.Value) []structField {
+	var fields []structField
+	iter, _ := v.Fields(cue.Optional(true))
+	for iter.Next() {
+		fields = append(fields, structField{iter.Label(), iter.Value(), iter.IsOptional()})
+	}
+	src, ok := v.Source().(*ast.StructLit)
+	if !ok {
+		return fields
+	}
+	order := map[string]int{}
+	for i, d := range src.Elts {
+		if f, ok := d.(*ast.Field); ok {
+			if name, ok := ast.LabelName(f.Label); ok {
+				order[name] = i + 1
+			}
+		}
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		a, b := order[fields[i].label], order[fields[j].label]
+		return a != 0 && (b == 0 || a < b)
+	})
+	return fields
+}
+
+func writeDoc(w io.Writer, doc []*ast.CommentGroup) {
+	for _, c := range doc {
+		for _, c := range c.List {
+			fmt.Fprintln(w, c.Text)
+		}
+	}
+}
+
+// typeExpr returns the Go type for v. Structs and enums are declared as
+// named types with the given name.
+func (g *goGenerator) typeExpr(name string, v cue.Value) string {
+	if _, path := v.Reference(); len(path) == 1 {
+		if t, ok := g.types[path[0]]; ok {
+			return t
+		}
+	}
+
+	if _, isEnum := enumValues(v); isEnum || isStruct(v) {
+		g.nested = append(g.nested, goDecl{name: name, v: v})
+		return name
+	}
+
+	// A disjunction with null.
+	if op, args := v.Expr(); op == cue.OrOp {
+		var rest []cue.Value
+		for _, a := range args {
+			if a.Kind() != cue.NullKind {
+				rest = append(rest, a)
+			}
+		}
+		if len(rest) == 1 && len(args) > 1 {
+			return pointer(g.typeExpr(name, rest[0]))
+		}
+	}
+
+	k := cueKind(v)
+	t := "interface{}"
+	switch k &^ cue.NullKind {
+	case cue.StructKind:
+		if elem, ok := v.Elem(); ok {
+			t = "map[string]" + g.typeExpr(name+"Value", elem)
+		}
+	case cue.ListKind:
+		t = "[]interface{}"
+		if elem, ok := v.Elem(); ok {
+			t = "[]" + g.typeExpr(name+"Elem", elem)
+		}
+	case cue.IntKind:
+		t = "int"
+	case cue.FloatKind, cue.NumberKind:
+		t = "float64"
+	case cue.StringKind:
+		t = "string"
+	case cue.BytesKind:
+		t = "[]byte"
+	case cue.BoolKind:
+		t = "bool"
+	}
+	if k&cue.NullKind != 0 && k != cue.NullKind {
+		t = pointer(t)
+	}
+	return t
+}
+
+// enumValues returns the values of v if v is a disjunction of strings.
+func enumValues(v cue.Value) (values []string, ok bool) {
+	op, args := v.Expr()
+	if op != cue.OrOp {
+		return nil, false
+	}
+	for _, a := range args {
+		s, err := a.String()
+		if a.Kind() != cue.StringKind || err != nil {
+			return nil, false
+		}
+		values = append(values, s)
+	}
+	return values, true
+}
+
+// isStruct reports whether v should be converted to a Go struct, rather than
+// a map.
+func isStruct(v cue.Value) bool {
+	if cueKind(v) != cue.StructKind {
+		return false
+	}
+	if v.Template() == nil {
+		return true
+	}
+	iter, err := v.Fields(cue.Optional(true))
+	return err == nil && iter.Next()
+}
+
+// cueKind returns the possible kinds of v, ignoring errors.
+func cueKind(v cue.Value) cue.Kind {
+	return v.IncompleteKind() &^ cue.BottomKind
+}
+
+// pointer returns a pointer to type t, unless t can already be nil.
+func pointer(t string) string {
+	for _, p := range []string{"*", "[]", "map[", "interface{"} {
+		if strings.HasPrefix(t, p) {
+			return t
+		}
+	}
+	return "*" + t
+}
+
+// goName converts a CUE label to an exported Go identifier.
+func goName(label string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range label {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r):
+			if upper {
+				r = unicode.ToUpper(r)
+				upper = false
+			}
+			if b.Len() == 0 && unicode.IsDigit(r) {
+				b.WriteByte('X')
+			}
+			b.WriteRune(r)
+		default:
+			upper = true
+		}
+	}
+	return b.String()
+}
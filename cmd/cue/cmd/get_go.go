@@ -112,6 +112,22 @@ Go structs are converted to cue structs adhering to the following conventions:
 	  The @go attribute is added if the field name or type definition differs
 	  between the generated CUE and the original Go.
 
+	- validation rules of fields and types are translated to CUE constraints.
+	  Supported are the rules of "validate" and "binding" struct tags as
+	  used by github.com/go-playground/validator, such as
+	  validate:"min=1,max=10,oneof=a b", and "+kubebuilder:validation:"
+	  markers in doc comments, such as +kubebuilder:validation:Minimum=1.
+	  The field
+
+	    Replicas int ` + "`json:\"replicas\" validate:\"required,min=1\"`" + `
+
+	  translates to
+
+	    replicas: int & >=1
+
+	  Rules that cannot be expressed in CUE, such as a maximum number of
+	  list elements, are dropped. Use the --verbose flag to list them.
+
 
 Native CUE Constraints
 
@@ -154,7 +170,9 @@ Constants and Enums
 Go does not have an enum or sum type. Conventionally, a type that is supposed
 to be an enum is followed by a const block with the allowed values for that
 type. However, as that is only a guideline and not a hard rule, these cases
-cannot be translated to CUE disjunctions automatically.
+are only translated to CUE disjunctions if the type is marked as an enum with
+a "+enum" line in its doc comment, as is common for Kubernetes types, or if
+the --enums flag is given.
 
 Constant values, however, are generated in a way that makes it easy to convert
 a type to a proper enum using native CUE constraints. For instance, the Go type
@@ -195,6 +213,32 @@ This tells CUE that only the values enumerated by enumSwitch are valid
 values for Switch. Note that there are now two definitions of Switch.
 CUE handles this in the usual way by unifying the two definitions, in which case
 the more restrictive enum interpretation of Switch remains.
+
+For types marked as enums, this constraint is generated directly:
+
+	package foo
+
+	// +enum
+	type Switch int
+
+	const (
+		Off Switch = iota
+		On
+	)
+
+translates into
+
+	package foo
+
+	// +enum
+	Switch: enumSwitch
+
+	enumSwitch: Off | On
+
+	Off: Switch & 0
+	On:  Switch & 1
+
+The constants remain references, so Switch evaluates to 0 | 1.
 `,
 		// - TODO: interpret cuego's struct tags and annotations.
 
@@ -205,12 +249,15 @@ the more restrictive enum interpretation of Switch remains.
 
 	cmd.Flags().StringP(string(flagExclude), "e", "",
 		"comma-separated list of regexps of entries")
+	cmd.Flags().Bool(string(flagEnums), false,
+		"limit types with constants to the values of these constants")
 
 	return cmd
 }
 
 const (
 	flagExclude flagName = "exclude"
+	flagEnums   flagName = "enums"
 )
 
 var cueTestRoot string // the CUE module root for test purposes.
@@ -248,13 +295,14 @@ type extractor struct {
 	usedPkgs map[string]bool
 
 	// per file
-	w          *bytes.Buffer
-	cmap       ast.CommentMap
-	pkg        *packages.Package
-	consts     map[string][]string
-	pkgNames   map[string]string
-	usedInFile map[string]bool
-	indent     int
+	w            *bytes.Buffer
+	cmap         ast.CommentMap
+	pkg          *packages.Package
+	consts       map[string][]string
+	pkgNames     map[string]string
+	usedInFile   map[string]bool
+	usedBuiltins map[string]bool // CUE builtin packages used in the file
+	indent       int
 
 	exclusions []*regexp.Regexp
 	exclude    string
@@ -406,6 +454,7 @@ func (e *extractor) extractPkg(root string, p *packages.Package) error {
 
 		e.pkgNames = map[string]string{}
 		e.usedInFile = map[string]bool{}
+		e.usedBuiltins = map[string]bool{}
 
 		for _, spec := range f.Imports {
 			key, _ := strconv.Unquote(spec.Path.Value)
@@ -435,6 +484,11 @@ func (e *extractor) extractPkg(root string, p *packages.Package) error {
 		for k := range e.usedInFile {
 			pkgs = append(pkgs, k)
 		}
+		for k := range e.usedBuiltins {
+			if !e.usedInFile[k] {
+				pkgs = append(pkgs, k)
+			}
+		}
 		sort.Strings(pkgs)
 
 		w := &bytes.Buffer{}
@@ -454,7 +508,7 @@ func (e *extractor) extractPkg(root string, p *packages.Package) error {
 			fmt.Fprintln(w, "import (")
 			for _, s := range pkgs {
 				name := e.pkgNames[s]
-				if p.Imports[s].Name == name {
+				if imp := p.Imports[s]; imp == nil || imp.Name == name {
 					fmt.Fprintf(w, "%q\n", s)
 				} else {
 					fmt.Fprintf(w, "%v %q\n", name, s)
@@ -567,7 +621,14 @@ func (e *extractor) reportDecl(w io.Writer, x *ast.GenDecl) (added bool) {
 			typ := e.pkg.TypesInfo.TypeOf(v.Name)
 			enums := e.consts[typ.String()]
 			name := v.Name.Name
+			closed := len(enums) > 0 &&
+				(flagEnums.Bool(e.cmd) || hasEnumMarker(x.Doc) || hasEnumMarker(v.Doc))
 			switch tn, ok := e.pkg.TypesInfo.Defs[v.Name].(*types.TypeName); {
+			case closed:
+				e.printDoc(x.Doc, true)
+				fmt.Fprintf(e.w, "%s: enum%s", name, name)
+				added = true
+
 			case ok:
 				if altType := e.altType(tn.Type()); altType != "" {
 					// TODO: add the underlying tag as a Go tag once we have
@@ -591,14 +652,16 @@ func (e *extractor) reportDecl(w io.Writer, x *ast.GenDecl) (added bool) {
 					fmt.Fprint(e.w, name, ": ", s)
 					break
 				}
-				// TODO: only print original type if value is not marked as enum.
 				underlying := e.pkg.TypesInfo.TypeOf(v.Type)
 				e.printField(name, false, underlying, x.Doc, true)
+				e.printConstraints(e.validationConstraints(name, underlying, "", x.Doc))
 			}
 
 			e.indent++
 			if len(enums) > 0 {
-				fmt.Fprintf(e.w, " // enum%s", name)
+				if !closed {
+					fmt.Fprintf(e.w, " // enum%s", name)
+				}
 
 				e.newLine()
 				e.newLine()
@@ -682,6 +745,20 @@ func (e *extractor) reportDecl(w io.Writer, x *ast.GenDecl) (added bool) {
 	return added
 }
 
+// hasEnumMarker reports whether doc contains a +enum marker, which indicates
+// that the constants of a type are its only valid values.
+func hasEnumMarker(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(strings.TrimPrefix(c.Text, "//")) == "+enum" {
+			return true
+		}
+	}
+	return false
+}
+
 func shortTypeName(t types.Type) string {
 	if n, ok := t.(*types.Named); ok {
 		return n.Obj().Name()
@@ -957,8 +1034,12 @@ func (e *extractor) printFields(x *types.Struct) {
 		if name == "-" {
 			continue
 		}
+		c := e.validationConstraints(f.Name(), f.Type(), tag, docs[i])
+		opt := (e.isOptional(tag) || c.optional) && !c.required
+
 		e.newLine()
-		cueType := e.printField(name, e.isOptional(tag), f.Type(), docs[i], count > 0)
+		cueType := e.printField(name, opt, f.Type(), docs[i], count > 0)
+		e.printConstraints(c)
 
 		// Add field tag to convert back to Go.
 		typeName := f.Type().String()
@@ -1020,6 +1101,17 @@ func (e *extractor) printFields(x *types.Struct) {
 	}
 }
 
+// printConstraints unifies the field or type printed last with the
+// constraints translated from its validation rules.
+func (e *extractor) printConstraints(c *constraints) {
+	for _, x := range c.exprs {
+		fmt.Fprint(e.w, " & ", x)
+	}
+	for pkg := range c.pkgs {
+		e.usedBuiltins[pkg] = true
+	}
+}
+
 func (e *extractor) isInline(tag string) bool {
 	return hasFlag(tag, "json", "inline", 1) ||
 		hasFlag(tag, "yaml", "inline", 1)
@@ -0,0 +1,376 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A valueKind classifies Go types by the validation rules that apply to them.
+type valueKind int
+
+const (
+	otherKind valueKind = iota
+	stringKind
+	numberKind
+	listKind
+)
+
+func kindOf(t types.Type) valueKind {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	switch x := t.Underlying().(type) {
+	case *types.Basic:
+		switch info := x.Info(); {
+		case info&types.IsString != 0:
+			return stringKind
+		case info&types.IsNumeric != 0:
+			return numberKind
+		}
+	case *types.Slice:
+		if !isByte(x.Elem()) {
+			return listKind
+		}
+	case *types.Array:
+		if !isByte(x.Elem()) {
+			return listKind
+		}
+	}
+	return otherKind
+}
+
+func isByte(t types.Type) bool {
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Kind() == types.Byte
+}
+
+// A rule is a single validation rule, such as min=1.
+type rule struct {
+	name string
+	arg  string
+}
+
+// constraints holds the result of translating the validation rules of a
+// field or type.
+type constraints struct {
+	exprs    []string        // CUE expressions to unify with the type
+	pkgs     map[string]bool // builtin packages used by exprs
+	required bool
+	optional bool
+}
+
+func (c *constraints) add(pkg, format string, args ...interface{}) {
+	c.exprs = append(c.exprs, fmt.Sprintf(format, args...))
+	if pkg != "" {
+		if c.pkgs == nil {
+			c.pkgs = map[string]bool{}
+		}
+		c.pkgs[pkg] = true
+	}
+}
+
+// A ruleFunc adds the constraints for rule r, which is one of all, to c for a
+// value of kind k. It reports whether the rule could be translated.
+type ruleFunc func(c *constraints, k valueKind, r rule, all []rule) bool
+
+// tagVocabularies maps the keys of struct tags to the validation rules that
+// may appear in their values.
+var tagVocabularies = map[string]map[string]ruleFunc{
+	"validate": validateRules, // github.com/go-playground/validator
+	"binding":  validateRules, // github.com/gin-gonic/gin
+}
+
+// markerVocabularies maps prefixes of comment markers to the validation
+// rules that may follow them.
+var markerVocabularies = map[string]map[string]ruleFunc{
+	"+kubebuilder:validation:": kubebuilderRules,
+}
+
+// validationConstraints translates the validation rules in the given struct
+// tag and doc comment for a value of type t.
+func (e *extractor) validationConstraints(name string, t types.Type, tag string, doc *ast.CommentGroup) *constraints {
+	c := &constraints{}
+	k := kindOf(t)
+	for _, key := range sortedKeys(tagVocabularies) {
+		if v, ok := reflect.StructTag(tag).Lookup(key); ok {
+			n := len(c.exprs)
+			rules := parseTagRules(v)
+			e.addRules(c, name, k, tagVocabularies[key], rules)
+			// With omitempty, the other rules do not apply to zero values.
+			if _, ok := hasRule(rules, "omitempty"); ok && len(c.exprs) > n {
+				zero := map[valueKind]string{stringKind: `""`, numberKind: "0", listKind: "[]"}[k]
+				x := fmt.Sprintf("(%s | %s)", zero, strings.Join(c.exprs[n:], " & "))
+				c.exprs = append(c.exprs[:n], x)
+			}
+		}
+	}
+	if doc != nil {
+		for _, prefix := range sortedKeys(markerVocabularies) {
+			e.addRules(c, name, k, markerVocabularies[prefix], parseMarkers(prefix, doc))
+		}
+	}
+	return c
+}
+
+func sortedKeys(m map[string]map[string]ruleFunc) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (e *extractor) addRules(c *constraints, name string, k valueKind, rules map[string]ruleFunc, all []rule) {
+	for _, r := range all {
+		f := rules[r.name]
+		if f == nil || !f(c, k, r, all) {
+			e.logf("    Dropped validation rule %q for %v", r.name, name)
+		}
+	}
+}
+
+// parseTagRules parses the comma-separated rules of a validator struct tag.
+// Rules following dive or keys apply to elements and are not returned.
+func parseTagRules(tag string) (rules []rule) {
+	for _, s := range strings.Split(tag, ",") {
+		r := rule{name: s}
+		if p := strings.IndexByte(s, '='); p >= 0 {
+			r = rule{name: s[:p], arg: s[p+1:]}
+		}
+		switch r.name {
+		case "":
+			continue
+		case "dive", "keys":
+			return rules
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// parseMarkers returns the rules of the comment markers in doc that start
+// with the given prefix, such as +kubebuilder:validation:Minimum=1.
+func parseMarkers(prefix string, doc *ast.CommentGroup) (rules []rule) {
+	for _, c := range doc.List {
+		s := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+		if !strings.HasPrefix(s, prefix) {
+			continue
+		}
+		s = s[len(prefix):]
+		r := rule{name: s}
+		if p := strings.IndexByte(s, '='); p >= 0 {
+			r = rule{name: s[:p], arg: s[p+1:]}
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+func hasRule(all []rule, name string) (rule, bool) {
+	for _, r := range all {
+		if r.name == name {
+			return r, true
+		}
+	}
+	return rule{}, false
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+func isCount(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 0
+}
+
+// bound adds a constraint for the minimum or maximum of a number, the number
+// of runes of a string, or the minimum number of elements of a list.
+//
+// The maximum number of elements of a list is not translated: list.MaxItems
+// is not enforced when unified with a list type, and a list pattern cannot
+// express an upper bound for an open list.
+func bound(c *constraints, k valueKind, op, arg string, isMax bool) bool {
+	switch {
+	case k == numberKind && isNumber(arg):
+		c.add("", "%s%s", op, arg)
+	case k == stringKind && isCount(arg) && isMax:
+		c.add("strings", "strings.MaxRunes(%s)", arg)
+	case k == stringKind && isCount(arg):
+		c.add("strings", "strings.MinRunes(%s)", arg)
+	case k == listKind && isCount(arg) && !isMax:
+		// list.MinItems would reject the list type itself, as it has no
+		// elements, so require the elements with a list pattern instead.
+		n, _ := strconv.Atoi(arg)
+		c.add("", "[%s...]", strings.Repeat("_, ", n))
+	default:
+		return false
+	}
+	return true
+}
+
+// oneOf adds a disjunction of the given values.
+func oneOf(c *constraints, k valueKind, values []string) bool {
+	if len(values) == 0 {
+		return false
+	}
+	for i, v := range values {
+		switch k {
+		case stringKind:
+			if s, err := strconv.Unquote(v); err == nil {
+				v = s
+			}
+			values[i] = strconv.Quote(v)
+		case numberKind:
+			if !isNumber(v) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	if len(values) == 1 {
+		c.add("", "%s", values[0])
+	} else {
+		c.add("", "(%s)", strings.Join(values, " | "))
+	}
+	return true
+}
+
+// compare adds a number comparison.
+func compare(op string) ruleFunc {
+	return func(c *constraints, k valueKind, r rule, all []rule) bool {
+		if k != numberKind || !isNumber(r.arg) {
+			return false
+		}
+		c.add("", "%s%s", op, r.arg)
+		return true
+	}
+}
+
+var validateRules = map[string]ruleFunc{
+	"omitempty": func(c *constraints, k valueKind, r rule, all []rule) bool {
+		return true
+	},
+	"required": func(c *constraints, k valueKind, r rule, all []rule) bool {
+		c.required = true
+		return true
+	},
+	"min": func(c *constraints, k valueKind, r rule, all []rule) bool {
+		return bound(c, k, ">=", r.arg, false)
+	},
+	"max": func(c *constraints, k valueKind, r rule, all []rule) bool {
+		return bound(c, k, "<=", r.arg, true)
+	},
+	"len": func(c *constraints, k valueKind, r rule, all []rule) bool {
+		switch k {
+		case numberKind:
+			return oneOf(c, k, []string{r.arg})
+		case listKind:
+			// Only the minimum could be enforced.
+			return false
+		}
+		return bound(c, k, "", r.arg, false) && bound(c, k, "", r.arg, true)
+	},
+	"eq": func(c *constraints, k valueKind, r rule, all []rule) bool {
+		return oneOf(c, k, []string{r.arg})
+	},
+	"ne": func(c *constraints, k valueKind, r rule, all []rule) bool {
+		switch {
+		case k == stringKind:
+			c.add("", "!=%s", strconv.Quote(r.arg))
+		case k == numberKind && isNumber(r.arg):
+			c.add("", "!=%s", r.arg)
+		default:
+			return false
+		}
+		return true
+	},
+	"gt":  compare(">"),
+	"gte": compare(">="),
+	"lt":  compare("<"),
+	"lte": compare("<="),
+	"oneof": func(c *constraints, k valueKind, r rule, all []rule) bool {
+		return oneOf(c, k, strings.Fields(r.arg))
+	},
+}
+
+var kubebuilderRules = map[string]ruleFunc{
+	"Required": func(c *constraints, k valueKind, r rule, all []rule) bool {
+		c.required = true
+		return true
+	},
+	"Optional": func(c *constraints, k valueKind, r rule, all []rule) bool {
+		c.optional = true
+		return true
+	},
+	"Minimum": func(c *constraints, k valueKind, r rule, all []rule) bool {
+		op := ">="
+		if x, ok := hasRule(all, "ExclusiveMinimum"); ok && x.arg != "false" {
+			op = ">"
+		}
+		return k == numberKind && bound(c, k, op, r.arg, false)
+	},
+	"Maximum": func(c *constraints, k valueKind, r rule, all []rule) bool {
+		op := "<="
+		if x, ok := hasRule(all, "ExclusiveMaximum"); ok && x.arg != "false" {
+			op = "<"
+		}
+		return k == numberKind && bound(c, k, op, r.arg, true)
+	},
+	"ExclusiveMinimum": func(c *constraints, k valueKind, r rule, all []rule) bool {
+		_, ok := hasRule(all, "Minimum")
+		return ok
+	},
+	"ExclusiveMaximum": func(c *constraints, k valueKind, r rule, all []rule) bool {
+		_, ok := hasRule(all, "Maximum")
+		return ok
+	},
+	"MinLength": func(c *constraints, k valueKind, r rule, all []rule) bool {
+		return k == stringKind && bound(c, k, "", r.arg, false)
+	},
+	"MaxLength": func(c *constraints, k valueKind, r rule, all []rule) bool {
+		return k == stringKind && bound(c, k, "", r.arg, true)
+	},
+	"MinItems": func(c *constraints, k valueKind, r rule, all []rule) bool {
+		return k == listKind && bound(c, k, "", r.arg, false)
+	},
+	"MaxItems": func(c *constraints, k valueKind, r rule, all []rule) bool {
+		return k == listKind && bound(c, k, "", r.arg, true)
+	},
+	"Pattern": func(c *constraints, k valueKind, r rule, all []rule) bool {
+		if k != stringKind {
+			return false
+		}
+		re := r.arg
+		if s, err := strconv.Unquote(re); err == nil {
+			re = s
+		}
+		c.add("", "=~%s", strconv.Quote(re))
+		return true
+	},
+	"Enum": func(c *constraints, k valueKind, r rule, all []rule) bool {
+		return oneOf(c, k, strings.Split(r.arg, ";"))
+	},
+}
@@ -0,0 +1,168 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/spf13/cobra"
+)
+
+// TestValidationConstraints checks that the constraints generated for
+// validation rules accept and reject the expected values when evaluated.
+func TestValidationConstraints(t *testing.T) {
+	var (
+		str     = types.Typ[types.String]
+		integer = types.Typ[types.Int]
+		strs    = types.NewSlice(str)
+	)
+	testCases := []struct {
+		typ     types.Type
+		cueType string
+		tag     string
+		markers []string
+		valid   []string
+		invalid []string
+		dropped []string
+	}{{
+		typ:     str,
+		cueType: "string",
+		tag:     `validate:"min=1,max=3"`,
+		valid:   []string{`"a"`, `"abc"`, `"äöü"`},
+		invalid: []string{`""`, `"abcd"`},
+	}, {
+		typ:     str,
+		cueType: "string",
+		tag:     `validate:"len=2"`,
+		valid:   []string{`"ab"`},
+		invalid: []string{`"a"`, `"abc"`},
+	}, {
+		typ:     str,
+		cueType: "string",
+		tag:     `validate:"omitempty,min=3"`,
+		valid:   []string{`""`, `"abc"`},
+		invalid: []string{`"ab"`},
+	}, {
+		typ:     integer,
+		cueType: "int",
+		tag:     `validate:"gte=1,lt=10"`,
+		valid:   []string{"1", "9"},
+		invalid: []string{"0", "10"},
+	}, {
+		typ:     integer,
+		cueType: "int",
+		tag:     `binding:"oneof=1 2"`,
+		valid:   []string{"1", "2"},
+		invalid: []string{"3"},
+	}, {
+		typ:     strs,
+		cueType: "[...string]",
+		tag:     `validate:"min=2"`,
+		valid:   []string{`["a", "b"]`, `["a", "b", "c"]`},
+		invalid: []string{`[]`, `["a"]`},
+	}, {
+		typ:     strs,
+		cueType: "[...string]",
+		tag:     `validate:"max=1,len=1"`,
+		valid:   []string{`[]`, `["a", "b"]`},
+		dropped: []string{"max", "len"},
+	}, {
+		typ:     strs,
+		cueType: "[...string]",
+		markers: []string{"MinItems=1", "MaxItems=2"},
+		valid:   []string{`["a"]`, `["a", "b", "c"]`},
+		invalid: []string{`[]`},
+		dropped: []string{"MaxItems"},
+	}, {
+		typ:     integer,
+		cueType: "int",
+		markers: []string{"Minimum=0", "ExclusiveMinimum=true", "Maximum=100"},
+		valid:   []string{"1", "100"},
+		invalid: []string{"0", "101"},
+	}, {
+		typ:     str,
+		cueType: "string",
+		markers: []string{"Pattern=`^[a-z]+$`", "Enum=alpha;beta", "MinLength=5"},
+		valid:   []string{`"alpha"`},
+		invalid: []string{`"beta"`, `"Alpha"`, `"gamma"`},
+	}}
+	for _, tc := range testCases {
+		name := tc.tag + strings.Join(tc.markers, ",")
+		t.Run(name, func(t *testing.T) {
+			var doc *ast.CommentGroup
+			if tc.markers != nil {
+				doc = &ast.CommentGroup{}
+				for _, m := range tc.markers {
+					doc.List = append(doc.List, &ast.Comment{
+						Text: "// +kubebuilder:validation:" + m,
+					})
+				}
+			}
+
+			cmd := &cobra.Command{}
+			cmd.Flags().Bool(string(flagVerbose), true, "")
+			log := &bytes.Buffer{}
+			e := &extractor{cmd: cmd, stderr: log}
+			c := e.validationConstraints("X", tc.typ, tc.tag, doc)
+
+			var dropped []string
+			for _, r := range tc.dropped {
+				dropped = append(dropped, fmt.Sprintf("    Dropped validation rule %q for X\n", r))
+			}
+			if got, want := log.String(), strings.Join(dropped, ""); got != want {
+				t.Errorf("log:\ngot  %q\nwant %q", got, want)
+			}
+
+			var pkgs []string
+			for pkg := range c.pkgs {
+				pkgs = append(pkgs, fmt.Sprintf("import %q\n", pkg))
+			}
+			sort.Strings(pkgs)
+			src := strings.Join(pkgs, "") +
+				"X: " + strings.Join(append([]string{tc.cueType}, c.exprs...), " & ")
+
+			var r cue.Runtime
+			inst, err := r.Compile("test", src)
+			if err != nil {
+				t.Fatalf("%s: %v", src, err)
+			}
+			check := func(data string, ok bool) {
+				t.Helper()
+				x, err := r.Compile("data", "X: "+data)
+				if err != nil {
+					t.Fatal(err)
+				}
+				v := inst.Value().Unify(x.Value())
+				err = v.Validate(cue.Concrete(true))
+				if (err == nil) != ok {
+					t.Errorf("%s & %s: got error %v; want error: %v", src, data, err, !ok)
+				}
+			}
+			for _, data := range tc.valid {
+				check(data, true)
+			}
+			for _, data := range tc.invalid {
+				check(data, false)
+			}
+		})
+	}
+}
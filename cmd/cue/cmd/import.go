@@ -15,6 +15,7 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -35,7 +36,9 @@ import (
 	"cuelang.org/go/cue/load"
 	"cuelang.org/go/cue/parser"
 	"cuelang.org/go/cue/token"
+	"cuelang.org/go/encoding/csv"
 	"cuelang.org/go/encoding/protobuf"
+	"cuelang.org/go/encoding/toml"
 	"cuelang.org/go/internal"
 	"cuelang.org/go/internal/third_party/yaml"
 	"github.com/spf13/cobra"
@@ -57,6 +60,9 @@ The following file formats are currently supported:
 	JSON       .json .jsonl .ndjson
 	YAML       .yaml .yml
 	protobuf   .proto
+	CSV        .csv
+	TSV        .tsv
+	TOML       .toml
 
 Files can either be specified explicitly, or inferred from the specified
 packages. In either case, the file extension is replaced with .cue. It will
@@ -92,6 +98,43 @@ used in combination with the -path flag, concatenating each entry to the
 mapped location.
 
 
+YAML anchors
+
+By default, YAML aliases are replaced with a copy of the value of their
+anchor. With the --yaml-anchors flag, an alias is instead converted to a
+reference to the field or element holding the anchored value, and a merge
+key (<<) is converted to the unification of the merged values with the
+remaining fields. Aliases that cannot be expressed as references, for
+instance merges with overridden fields, are still expanded.
+
+
+CSV and TSV files
+
+Each row of a CSV or TSV file is converted to a struct with a field for each
+column, named after the header row. Values that are numbers or booleans are
+converted accordingly; all other values are strings. The --csv-schema flag
+specifies a CUE struct that determines the types of columns instead: a
+column is only converted to a number or boolean if the field of the same
+name in the schema allows it. The rows are combined in a list, unless the
+-path flag is given, in which case each row is placed at the computed path.
+
+The --delimiter flag overrides the field delimiter, which defaults to a
+comma for CSV files and a tab for TSV files. With --no-header, the first row
+is treated as data and each row is converted to a list instead.
+
+  $ cat <<EOF > zips.csv
+  zip,city,population
+  02134,Boston,36000
+  EOF
+
+  $ cue import -o - zips.csv --csv-schema '{zip: string}' -l '"\(zip)"'
+  "02134": {
+      zip:        "02134"
+      city:       "Boston"
+      population: 36000
+  }
+
+
 Examples:
 
   $ cat <<EOF > foo.yaml
@@ -210,31 +253,47 @@ Example:
 	cmd.Flags().Bool(string(flagFiles), false, "split multiple entries into different files")
 	cmd.Flags().BoolP(string(flagRecursive), "R", false, "recursively parse string values")
 
-	cmd.Flags().String("fix", "", "apply given fix")
+	cmd.Flags().String(string(flagFix), "", "apply the given comma-separated fix rules")
 
 	cmd.Flags().StringArrayP(string(flagProtoPath), "I", nil, "paths in which to search for imports")
 
+	cmd.Flags().String(string(flagDelimiter), "", "field delimiter of CSV and TSV files")
+	cmd.Flags().Bool(string(flagNoHeader), false, "treat the first row of CSV and TSV files as data")
+	cmd.Flags().String(string(flagCSVSchema), "", "CUE struct determining the types of CSV and TSV columns")
+
+	cmd.Flags().Bool(string(flagYAMLAnchors), false, "convert YAML aliases to references to their anchors")
+
 	return cmd
 }
 
 const (
+	flagFix       flagName = "fix"
 	flagFiles     flagName = "files"
 	flagProtoPath flagName = "proto_path"
+	flagDelimiter flagName = "delimiter"
+	flagNoHeader  flagName = "no-header"
+	flagCSVSchema flagName = "csv-schema"
+
+	flagYAMLAnchors flagName = "yaml-anchors"
 )
 
-type importStreamFunc func(path string, r io.Reader) ([]ast.Expr, error)
+type importStreamFunc func(cmd *cobra.Command, path string, r io.Reader) ([]ast.Expr, error)
 type importFileFunc func(cmd *cobra.Command, path string, r io.Reader) (*ast.File, error)
 
 type encodingInfo struct {
 	fnStream importStreamFunc
 	fnFile   importFileFunc
 	typ      string
+	tabular  bool // rows are combined in a list by default
 }
 
 var (
 	jsonEnc     = &encodingInfo{fnStream: handleJSON, typ: "json"}
 	yamlEnc     = &encodingInfo{fnStream: handleYAML, typ: "yaml"}
 	protodefEnc = &encodingInfo{fnFile: handleProtoDef, typ: "proto"}
+	csvEnc      = &encodingInfo{fnStream: handleCSV, typ: "csv", tabular: true}
+	tsvEnc      = &encodingInfo{fnStream: handleTSV, typ: "tsv", tabular: true}
+	tomlEnc     = &encodingInfo{fnStream: handleTOML, typ: "toml"}
 )
 
 func getExtInfo(ext string) *encodingInfo {
@@ -249,6 +308,12 @@ func getExtInfo(ext string) *encodingInfo {
 		return yamlEnc
 	case "protobuf":
 		return protodefEnc
+	case "csv":
+		return csvEnc
+	case "tsv":
+		return tsvEnc
+	case "toml":
+		return tomlEnc
 	}
 	return nil
 }
@@ -340,11 +405,12 @@ func handleFile(cmd *cobra.Command, pkg, filename string) (err error) {
 		return processFile(cmd, file)
 
 	case handler.fnStream != nil:
-		objs, err := handler.fnStream(filename, f)
+		objs, err := handler.fnStream(cmd, filename, f)
 		if err != nil {
 			return err
 		}
-		return processStream(cmd, pkg, filename, objs)
+		list := flagList.Bool(cmd) || (handler.tabular && flagPath.String(cmd) == "")
+		return processStream(cmd, pkg, filename, list, objs)
 
 	default:
 		panic("incorrect handler")
@@ -362,27 +428,27 @@ func processFile(cmd *cobra.Command, file *ast.File) (err error) {
 	return ioutil.WriteFile(name, b, 0644)
 }
 
-func processStream(cmd *cobra.Command, pkg, filename string, objs []ast.Expr) error {
+func processStream(cmd *cobra.Command, pkg, filename string, list bool, objs []ast.Expr) error {
 	if flagFiles.Bool(cmd) {
 		for i, f := range objs {
-			err := combineExpressions(cmd, pkg, newName(filename, i), f)
+			err := combineExpressions(cmd, pkg, newName(filename, i), list, f)
 			if err != nil {
 				return err
 			}
 		}
 		return nil
 	} else if len(objs) > 1 {
-		if !flagList.Bool(cmd) && flagPath.String(cmd) == "" && !flagFiles.Bool(cmd) {
+		if !list && flagPath.String(cmd) == "" && !flagFiles.Bool(cmd) {
 			return fmt.Errorf("list, flag, or files flag needed to handle multiple objects in file %q", filename)
 		}
 	}
-	return combineExpressions(cmd, pkg, newName(filename, 0), objs...)
+	return combineExpressions(cmd, pkg, newName(filename, 0), list, objs...)
 }
 
 // TODO: implement a more fine-grained approach.
 var mutex sync.Mutex
 
-func combineExpressions(cmd *cobra.Command, pkg, cueFile string, objs ...ast.Expr) error {
+func combineExpressions(cmd *cobra.Command, pkg, cueFile string, list bool, objs ...ast.Expr) error {
 	mutex.Lock()
 	defer mutex.Unlock()
 
@@ -423,34 +489,17 @@ func combineExpressions(cmd *cobra.Command, pkg, cueFile string, objs ...ast.Exp
 
 		switch {
 		case flagPath.String(cmd) != "":
-			inst, err := runtime.CompileExpr(expr)
+			labels, err := parsePath(flagPath.String(cmd))
 			if err != nil {
 				return err
 			}
-
-			labels, err := parsePath(flagPath.String(cmd))
+			pathElems, err = evalPath(expr, labels)
 			if err != nil {
 				return err
 			}
-			for _, l := range labels {
-				switch x := l.(type) {
-				case *ast.Interpolation:
-					v := inst.Eval(x)
-					if v.Kind() == cue.BottomKind {
-						return v.Err()
-					}
-					pathElems = append(pathElems, v.Syntax().(ast.Label))
-
-				case *ast.Ident, *ast.BasicLit:
-					pathElems = append(pathElems, x)
-
-				case *ast.TemplateLabel:
-					return fmt.Errorf("template labels not supported in path flag")
-				}
-			}
 		}
 
-		if flagList.Bool(cmd) {
+		if list {
 			idx := index
 			for _, e := range pathElems {
 				idx = idx.label(e)
@@ -504,7 +553,7 @@ func combineExpressions(cmd *cobra.Command, pkg, cueFile string, objs ...ast.Exp
 		f.Decls = append([]ast.Decl{imports}, f.Decls...)
 	}
 
-	if flagList.Bool(cmd) {
+	if list {
 		switch x := index.field.Value.(type) {
 		case *ast.StructLit:
 			f.Decls = append(f.Decls, x.Elts...)
@@ -520,6 +569,20 @@ func combineExpressions(cmd *cobra.Command, pkg, cueFile string, objs ...ast.Exp
 		return fmt.Errorf("error formatting file: %v", err)
 	}
 
+	if fix := flagFix.String(cmd); fix != "" {
+		rules, err := lookupFixRules(strings.Split(fix, ","))
+		if err != nil {
+			return err
+		}
+		fixed, err := fixFile(cueFile, b, &fixConfig{}, rules)
+		if err != nil {
+			return err
+		}
+		if fixed != nil {
+			b = fixed
+		}
+	}
+
 	if cueFile == "-" {
 		_, err := cmd.OutOrStdout().Write(b)
 		return err
@@ -593,6 +656,32 @@ func parsePath(exprs string) (p []ast.Label, err error) {
 	return p, nil
 }
 
+// evalPath computes the labels for placing expr by evaluating the
+// interpolations of the given path within the scope of expr.
+func evalPath(expr ast.Expr, path []ast.Label) (labels []ast.Label, err error) {
+	inst, err := runtime.CompileExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range path {
+		switch x := l.(type) {
+		case *ast.Interpolation:
+			v := inst.Eval(x)
+			if v.Kind() == cue.BottomKind {
+				return nil, v.Err()
+			}
+			labels = append(labels, v.Syntax().(ast.Label))
+
+		case *ast.Ident, *ast.BasicLit:
+			labels = append(labels, x)
+
+		case *ast.TemplateLabel:
+			return nil, fmt.Errorf("template labels not supported in path flag")
+		}
+	}
+	return labels, nil
+}
+
 func newName(filename string, i int) string {
 	ext := filepath.Ext(filename)
 	filename = filename[:len(filename)-len(ext)]
@@ -603,9 +692,14 @@ func newName(filename string, i int) string {
 	return filename
 }
 
-func handleJSON(path string, r io.Reader) (objects []ast.Expr, err error) {
-	d := json.NewDecoder(r)
+func handleJSON(cmd *cobra.Command, path string, r io.Reader) (objects []ast.Expr, err error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
 
+	line, offset := 1, 0
 	for {
 		var raw json.RawMessage
 		err := d.Decode(&raw)
@@ -619,16 +713,27 @@ func handleJSON(path string, r io.Reader) (objects []ast.Expr, err error) {
 		if err != nil {
 			return nil, fmt.Errorf("invalid input: %v %q", err, raw)
 		}
+
+		// Report positions relative to the start of the input rather than
+		// the start of the object.
+		start := int(d.InputOffset()) - len(raw)
+		line += bytes.Count(b[offset:start], []byte("\n"))
+		offset = start
+		if f := expr.Pos().File(); f != nil && line > 1 {
+			f.AddLineInfo(0, path, line)
+		}
+
 		objects = append(objects, expr)
 	}
 	return objects, nil
 }
 
-func handleYAML(path string, r io.Reader) (objects []ast.Expr, err error) {
+func handleYAML(cmd *cobra.Command, path string, r io.Reader) (objects []ast.Expr, err error) {
 	d, err := yaml.NewDecoder(path, r)
 	if err != nil {
 		return nil, err
 	}
+	d.SetAnchorReferences(flagYAMLAnchors.Bool(cmd))
 	for i := 0; ; i++ {
 		expr, err := d.Decode()
 		if err == io.EOF {
@@ -642,6 +747,65 @@ func handleYAML(path string, r io.Reader) (objects []ast.Expr, err error) {
 	return objects, nil
 }
 
+func handleTOML(cmd *cobra.Command, path string, r io.Reader) ([]ast.Expr, error) {
+	f, err := toml.Extract(path, r)
+	if err != nil {
+		return nil, err
+	}
+	obj := &ast.StructLit{Elts: f.Decls}
+	for _, c := range f.Comments() {
+		obj.AddComment(c)
+	}
+	return []ast.Expr{obj}, nil
+}
+
+func handleCSV(cmd *cobra.Command, path string, r io.Reader) ([]ast.Expr, error) {
+	return handleTabular(cmd, path, r, ',')
+}
+
+func handleTSV(cmd *cobra.Command, path string, r io.Reader) ([]ast.Expr, error) {
+	return handleTabular(cmd, path, r, '\t')
+}
+
+// handleTabular converts the rows of a CSV file with the given default
+// delimiter.
+func handleTabular(cmd *cobra.Command, path string, r io.Reader, comma rune) (objects []ast.Expr, err error) {
+	cfg := &csv.Config{
+		Comma:    comma,
+		NoHeader: flagNoHeader.Bool(cmd),
+	}
+	if s := flagDelimiter.String(cmd); s != "" {
+		d := []rune(s)
+		if len(d) != 1 {
+			return nil, fmt.Errorf("delimiter must be a single character, found %q", s)
+		}
+		cfg.Comma = d[0]
+	}
+	if s := flagCSVSchema.String(cmd); s != "" {
+		inst, err := runtime.Compile("<csv-schema flag>", s)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Schema = inst.Value()
+	}
+
+	d, err := csv.NewDecoder(path, r, cfg)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		expr, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, expr)
+	}
+	return objects, nil
+}
+
 func handleProtoDef(cmd *cobra.Command, path string, r io.Reader) (f *ast.File, err error) {
 	return protobuf.Extract(path, r, &protobuf.Config{Paths: flagProtoPath.StringArray(cmd)})
 }
@@ -41,4 +41,26 @@ func TestImport(t *testing.T) {
 		"-l", `"\(strings.ToLower(kind))" "\(name)"`, "--recursive",
 	})
 	runCommand(t, cmd, "import_hoiststr")
+
+	cmd = newImportCmd()
+	cmd.ParseFlags([]string{"-o", "-", "-f"})
+	runCommand(t, cmd, "import_csv")
+
+	cmd = newImportCmd()
+	cmd.ParseFlags([]string{
+		"-o", "-", "-f", "--csv-schema", "{zip: string}", "-l", `"\(zip)"`,
+	})
+	runCommand(t, cmd, "import_csvschema")
+
+	cmd = newImportCmd()
+	cmd.ParseFlags([]string{"-o", "-", "-f"})
+	runCommand(t, cmd, "import_toml")
+
+	cmd = newImportCmd()
+	cmd.ParseFlags([]string{"-o", "-", "-f"})
+	runCommand(t, cmd, "import_yaml")
+
+	cmd = newImportCmd()
+	cmd.ParseFlags([]string{"-o", "-", "-f", "--yaml-anchors"})
+	runCommand(t, cmd, "import_yamlanchors")
 }
@@ -0,0 +1,278 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"github.com/spf13/cobra"
+	"golang.org/x/text/message"
+)
+
+const flagErrors flagName = "errors"
+
+const (
+	errorsText  = "text"
+	errorsJSON  = "json"
+	errorsSARIF = "sarif"
+)
+
+func addErrorsFlag(cmd *cobra.Command) {
+	cmd.Flags().String(string(flagErrors), errorsText,
+		"format of reported errors: text, json, or sarif")
+}
+
+// reports holds the error reports of commands that report errors in a
+// machine-readable format.
+var reports = map[*cobra.Command]*errorReport{}
+
+// An errorReport collects the errors of a command for machine-readable
+// output. The errors are written when the command completes.
+type errorReport struct {
+	format string
+	errs   []errors.Error
+}
+
+// withErrorReport wraps the RunE function of a command to write the errors
+// reported by the command in the format selected by the --errors flag.
+func withErrorReport(run func(cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		format := flagErrors.String(cmd)
+		switch format {
+		case "", errorsText:
+			return run(cmd, args)
+		case errorsJSON, errorsSARIF:
+		default:
+			return fmt.Errorf("unsupported error format %q", format)
+		}
+
+		r := &errorReport{format: format}
+		reports[cmd] = r
+		defer func() {
+			delete(reports, cmd)
+			r.write(cmd.OutOrStderr())
+		}()
+		return run(cmd, args)
+	}
+}
+
+func (r *errorReport) add(err error) {
+	r.errs = append(r.errs, errors.Errors(err)...)
+}
+
+func (r *errorReport) write(w io.Writer) {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	switch r.format {
+	case errorsJSON:
+		for _, e := range r.errs {
+			_ = enc.Encode(newJSONError(e))
+		}
+	case errorsSARIF:
+		enc.SetIndent("", "    ")
+		_ = enc.Encode(newSARIFLog(r.errs))
+	}
+}
+
+type jsonError struct {
+	Message        string         `json:"message"`
+	Format         string         `json:"format"`
+	Args           []string       `json:"args,omitempty"`
+	Path           []string       `json:"path,omitempty"`
+	Position       *jsonPosition  `json:"position,omitempty"`
+	InputPositions []jsonPosition `json:"inputPositions,omitempty"`
+}
+
+type jsonPosition struct {
+	Filename string `json:"filename,omitempty"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+func newJSONError(e errors.Error) *jsonError {
+	format, args := e.Msg()
+	x := &jsonError{
+		Message: errorMessage(e),
+		Format:  format,
+		Path:    e.Path(),
+	}
+	for _, a := range args {
+		x.Args = append(x.Args, fmt.Sprint(a))
+	}
+	if p := e.Position(); p.IsValid() {
+		x.Position = newJSONPosition(p)
+	}
+	for _, p := range inputPositions(e) {
+		x.InputPositions = append(x.InputPositions, *newJSONPosition(p))
+	}
+	return x
+}
+
+func newJSONPosition(p token.Pos) *jsonPosition {
+	return &jsonPosition{
+		Filename: relFilename(p.Filename()),
+		Line:     p.Line(),
+		Column:   p.Column(),
+	}
+}
+
+// errorMessage returns the localized message of e, without position
+// information.
+func errorMessage(e errors.Error) string {
+	p := message.NewPrinter(getLang())
+	format, args := e.Msg()
+	return p.Sprintf(format, args...)
+}
+
+// inputPositions returns the valid input positions of e, excluding its
+// primary position.
+func inputPositions(e errors.Error) []token.Pos {
+	a := errors.Positions(e)
+	if len(a) > 0 && a[0] == e.Position() {
+		a = a[1:]
+	}
+	return a
+}
+
+// relFilename returns filename relative to the current directory, if
+// possible.
+func relFilename(filename string) string {
+	if filename == "" || !filepath.IsAbs(filename) {
+		return filepath.ToSlash(filename)
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(cwd, filename); err == nil &&
+			!strings.HasPrefix(rel, "..") {
+			filename = rel
+		}
+	}
+	return filepath.ToSlash(filename)
+}
+
+// The following types define the subset of the Static Analysis Results
+// Interchange Format (SARIF) 2.1.0 used to report errors.
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string `json:"name"`
+	InformationURI string `json:"informationUri"`
+}
+
+type sarifResult struct {
+	Level            string          `json:"level"`
+	Message          sarifMessage    `json:"message"`
+	Locations        []sarifLocation `json:"locations,omitempty"`
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	ID               *int                   `json:"id,omitempty"`
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+func newSARIFLog(errs []errors.Error) *sarifLog {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "cue",
+			InformationURI: "https://cuelang.org",
+		}},
+		Results: []sarifResult{},
+	}
+	for _, e := range errs {
+		res := sarifResult{
+			Level:   "error",
+			Message: sarifMessage{Text: errorMessage(e)},
+		}
+		loc := sarifLocation{}
+		if p := e.Position(); p.IsValid() {
+			loc.PhysicalLocation = newSARIFPhysicalLocation(p)
+		}
+		if path := e.Path(); len(path) > 0 {
+			loc.LogicalLocations = []sarifLogicalLocation{{
+				FullyQualifiedName: strings.Join(path, "."),
+			}}
+		}
+		if loc.PhysicalLocation != nil || loc.LogicalLocations != nil {
+			res.Locations = []sarifLocation{loc}
+		}
+		for i, p := range inputPositions(e) {
+			id := i
+			res.RelatedLocations = append(res.RelatedLocations, sarifLocation{
+				ID:               &id,
+				PhysicalLocation: newSARIFPhysicalLocation(p),
+			})
+		}
+		run.Results = append(run.Results, res)
+	}
+	return &sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	}
+}
+
+func newSARIFPhysicalLocation(p token.Pos) *sarifPhysicalLocation {
+	return &sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: relFilename(p.Filename())},
+		Region: sarifRegion{
+			StartLine:   p.Line(),
+			StartColumn: p.Column(),
+		},
+	}
+}
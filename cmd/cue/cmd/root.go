@@ -28,13 +28,9 @@ import (
 )
 
 // TODO: commands
-//   fix:      rewrite/refactor configuration files
-//             -i interactive: open diff and ask to update
 //   serve:    like cmd, but for servers
 //   get:      convert cue from other languages, like proto and go.
-//   gen:      generate files for other languages
 //   generate  like go generate (also convert cue to go doc)
-//   test      load and fully evaluate test files.
 //
 // TODO: documentation of concepts
 //   tasks     the key element for cmd, serve, and fix
@@ -76,12 +72,17 @@ For more information on writing CUE configuration files see cuelang.org.`,
 		newImportCmd(),
 		newEvalCmd(),
 		newGetCmd(),
+		newGenCmd(),
 		newFmtCmd(),
 		newExportCmd(),
 		cmdCmd,
 		newVersionCmd(),
 		newVetCmd(),
 		newAddCmd(),
+		newTestCmd(),
+		newFixCmd(),
+		newCompletionCmd(),
+		newCompleteCmd(),
 	}
 
 	addGlobalFlags(cmd.PersistentFlags())
@@ -0,0 +1,259 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/token"
+)
+
+const flagSuggest flagName = "suggest"
+
+// templateName is the name of the label of suggested templates.
+const templateName = "Name"
+
+// A suggestion is a template proposed for the values of a struct.
+type suggestion struct {
+	path   []string
+	values int // number of values to which the template applies
+	common int // number of fields defined by the template
+	total  int // number of fields of the values before applying the template
+	decls  []ast.Decl
+}
+
+// reduced reports the number of fields of the values after applying the
+// template, including the fields of the template itself.
+func (s *suggestion) reduced() int {
+	return s.total - s.common*s.values + s.common
+}
+
+// saved reports the number of fields saved by applying the template.
+func (s *suggestion) saved() int {
+	return s.common * (s.values - 1)
+}
+
+// suggestTemplates appends to a a suggestion for each struct within v of
+// which all values are structs that have fields in common. If both a struct
+// and structs nested within its values qualify, the suggestions that save
+// the most fields are used.
+func suggestTemplates(a []suggestion, v cue.Value, path []string) []suggestion {
+	if v.Kind() != cue.StructKind {
+		return a
+	}
+
+	var labels []string
+	var values, implied []cue.Value
+	allStructs := true
+	fn := v.Template()
+	for iter, _ := v.Fields(); iter.Next(); {
+		labels = append(labels, iter.Label())
+		values = append(values, iter.Value())
+		if fn != nil {
+			implied = append(implied, fn(iter.Label()))
+		}
+		if iter.Value().Kind() != cue.StructKind {
+			allStructs = false
+		}
+	}
+
+	var nested []suggestion
+	for i, v := range values {
+		p := append(path[:len(path):len(path)], labels[i])
+		nested = suggestTemplates(nested, v, p)
+	}
+
+	if allStructs && len(values) > 1 {
+		decls, n := commonFields(labels, values, implied)
+		s := suggestion{
+			path:   path,
+			values: len(values),
+			common: n,
+			decls:  decls,
+		}
+		saved := 0
+		for _, x := range nested {
+			saved += x.saved()
+		}
+		if n > 0 && s.saved() > saved {
+			for _, v := range values {
+				s.total += countFields(v)
+			}
+			return append(a, s)
+		}
+	}
+	return append(a, nested...)
+}
+
+// commonFields returns the fields that have the same value in all of the
+// given structs, along with the number of such fields, counting the fields of
+// nested structs instead of the structs themselves. String fields that equal
+// the label of each struct are defined in terms of the template label.
+//
+// Fields that are already implied by an existing template, as given by the
+// values of implied, are not included.
+func commonFields(labels []string, values, implied []cue.Value) (decls []ast.Decl, n int) {
+	for iter, _ := values[0].Fields(); iter.Next(); {
+		name := iter.Label()
+
+		sub := make([]cue.Value, 0, len(values))
+		for _, v := range values {
+			if w := v.Lookup(name); w.Exists() {
+				sub = append(sub, w)
+			}
+		}
+		if len(sub) != len(values) {
+			continue
+		}
+
+		var subImplied []cue.Value
+		isImplied := implied != nil
+		for _, v := range implied {
+			w := v.Lookup(name)
+			subImplied = append(subImplied, w)
+			isImplied = isImplied && w.Exists()
+		}
+		if !isImplied {
+			subImplied = nil
+		}
+
+		var value ast.Expr
+		switch {
+		case allKind(sub, cue.StructKind):
+			elts, k := commonFields(labels, sub, subImplied)
+			if k == 0 {
+				continue
+			}
+			value = &ast.StructLit{Elts: elts}
+			n += k
+
+		case allSame(sub):
+			if isImplied && allSame(append(sub[:1:1], subImplied...)) {
+				continue
+			}
+			value = exprOf(sub[0])
+			n++
+
+		case matchesLabels(labels, sub):
+			if isImplied && matchesLabels(labels, subImplied) {
+				continue
+			}
+			value = ast.NewIdent(templateName)
+			n++
+
+		default:
+			continue
+		}
+		decls = append(decls, &ast.Field{
+			Label: ast.NewIdent(name),
+			Value: value,
+		})
+	}
+	return decls, n
+}
+
+func exprOf(v cue.Value) ast.Expr {
+	switch x := v.Syntax().(type) {
+	case ast.Expr:
+		return x
+	case *ast.File:
+		return &ast.StructLit{Elts: x.Decls}
+	}
+	return &ast.BottomLit{}
+}
+
+func allKind(values []cue.Value, k cue.Kind) bool {
+	for _, v := range values {
+		if v.Kind() != k {
+			return false
+		}
+	}
+	return true
+}
+
+func allSame(values []cue.Value) bool {
+	for _, v := range values[1:] {
+		if !sameValue(values[0], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesLabels reports whether each of the values is a string equal to the
+// corresponding label.
+func matchesLabels(labels []string, values []cue.Value) bool {
+	for i, v := range values {
+		if s, err := v.String(); err != nil || s != labels[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// countFields reports the number of fields of v, counting the fields of
+// nested structs instead of the structs themselves.
+func countFields(v cue.Value) int {
+	n := 0
+	for iter, _ := v.Fields(); iter.Next(); {
+		if iter.Value().Kind() == cue.StructKind {
+			n += countFields(iter.Value())
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// writeSuggestions writes each suggestion to w as a template preceded by a
+// comment describing the reduction it achieves.
+func writeSuggestions(w io.Writer, a []suggestion) error {
+	for i, s := range a {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		name := strings.Join(s.path, " ")
+		if name == "" {
+			name = "top level"
+		}
+		fmt.Fprintf(w, "// %s: reduces %d fields in %d values to %d (%d in common)\n",
+			name, s.total, s.values, s.reduced(), s.common)
+
+		var label ast.Label = &ast.TemplateLabel{Ident: ast.NewIdent(templateName)}
+		f := &ast.Field{Label: label, Value: &ast.StructLit{
+			Lbrace: token.Blank.Pos(),
+			Elts:   s.decls,
+			Rbrace: token.Newline.Pos(),
+		}}
+		for i := len(s.path) - 1; i >= 0; i-- {
+			f = &ast.Field{
+				Label: ast.NewIdent(s.path[i]),
+				Value: &ast.StructLit{Elts: []ast.Decl{f}},
+			}
+		}
+		b, err := format.Node(&ast.File{Decls: []ast.Decl{f}}, format.Simplify())
+		if err != nil {
+			return err
+		}
+		w.Write(bytes.TrimLeft(b, "\n"))
+	}
+	return nil
+}
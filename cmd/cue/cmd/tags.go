@@ -0,0 +1,182 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
+	"github.com/spf13/cobra"
+)
+
+// A taggedField is a field with a @tag attribute.
+type taggedField struct {
+	field *ast.Field
+	tag   string
+	path  []string
+}
+
+// parseTags parses the values of the --inject flag.
+func parseTags(cmd *cobra.Command) (map[string]string, error) {
+	tags := map[string]string{}
+	for _, s := range flagInject.StringArray(cmd) {
+		p := strings.SplitN(s, "=", 2)
+		if len(p) != 2 || p[0] == "" {
+			return nil, errors.Newf(token.NoPos,
+				"invalid tag %q: must be of the form key=value", s)
+		}
+		tags[p[0]] = p[1]
+	}
+	return tags, nil
+}
+
+// injectTags sets the fields of the given instances that have a @tag(name)
+// attribute to the value given for name with the --inject flag. The value
+// is interpreted according to the type of the field. It is an error if a tag
+// does not correspond to any field.
+func injectTags(cmd *cobra.Command, binst []*build.Instance) error {
+	tags, err := parseTags(cmd)
+	if err != nil || len(tags) == 0 {
+		return err
+	}
+
+	used := map[string]bool{}
+	for _, b := range binst {
+		var fields []taggedField
+		for _, f := range b.Files {
+			fields = appendTaggedFields(fields, f.Decls, nil)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		// Build the instance once to determine the types of the fields.
+		inst := cue.Build([]*build.Instance{b})[0]
+		if inst.Err != nil {
+			return inst.Err
+		}
+		for _, t := range fields {
+			s, ok := tags[t.tag]
+			if !ok {
+				continue
+			}
+			used[t.tag] = true
+			kind := inst.Lookup(t.path...).IncompleteKind()
+			x, err := tagValue(t.tag, s, kind)
+			if err != nil {
+				return err
+			}
+			t.field.Value = &ast.BinaryExpr{X: t.field.Value, Op: token.AND, Y: x}
+		}
+	}
+
+	for name := range tags {
+		if !used[name] {
+			return errors.Newf(token.NoPos, "no field with tag %q", name)
+		}
+	}
+	return nil
+}
+
+// appendTaggedFields appends the fields with a @tag attribute in decls and
+// the structs nested within them.
+func appendTaggedFields(a []taggedField, decls []ast.Decl, path []string) []taggedField {
+	for _, d := range decls {
+		field, ok := d.(*ast.Field)
+		if !ok {
+			continue
+		}
+		name, ok := ast.LabelName(field.Label)
+		if !ok {
+			continue
+		}
+		p := append(path[:len(path):len(path)], name)
+		for _, attr := range field.Attrs {
+			if tag, ok := tagName(attr.Text); ok {
+				a = append(a, taggedField{field: field, tag: tag, path: p})
+			}
+		}
+		if s, ok := field.Value.(*ast.StructLit); ok {
+			a = appendTaggedFields(a, s.Elts, p)
+		}
+	}
+	return a
+}
+
+// tagName returns the name of a @tag(name) attribute.
+func tagName(attr string) (string, bool) {
+	const prefix = "@tag("
+	if !strings.HasPrefix(attr, prefix) || !strings.HasSuffix(attr, ")") {
+		return "", false
+	}
+	body := attr[len(prefix) : len(attr)-1]
+	name := strings.TrimSpace(strings.SplitN(body, ",", 2)[0])
+	return name, name != ""
+}
+
+// tagValue converts the value s for the given tag to an expression of the
+// given kind.
+func tagValue(tag, s string, kind cue.Kind) (ast.Expr, error) {
+	str := &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(s)}
+	if kind&^cue.StringKind == 0 {
+		return str, nil
+	}
+	if x, err := parser.ParseExpr("<inject flag>", s); err == nil && isLiteral(x) {
+		return x, nil
+	}
+	if kind&cue.StringKind != 0 {
+		return str, nil
+	}
+	return nil, errors.Newf(token.NoPos,
+		"invalid value %q for tag %q: must be %s", s, tag, kindString(kind))
+}
+
+func kindString(k cue.Kind) string {
+	var a []string
+	for _, x := range []struct {
+		kind cue.Kind
+		name string
+	}{
+		{cue.NullKind, "null"},
+		{cue.BoolKind, "bool"},
+		{cue.IntKind, "int"},
+		{cue.FloatKind, "float"},
+		{cue.StringKind, "string"},
+		{cue.BytesKind, "bytes"},
+		{cue.StructKind, "struct"},
+		{cue.ListKind, "list"},
+	} {
+		if k&x.kind != 0 {
+			a = append(a, x.name)
+		}
+	}
+	return strings.Join(a, "|")
+}
+
+func isLiteral(x ast.Expr) bool {
+	switch x := x.(type) {
+	case *ast.BasicLit:
+		return true
+	case *ast.UnaryExpr:
+		return x.Op == token.SUB && isLiteral(x.X)
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import "testing"
+
+func TestInjectTags(t *testing.T) {
+	runCommand(t, newRootCmd().root, "eval_tags",
+		"eval", "-t", "env=prod", "-t", "replicas=3", "-t", "port=9090", "-t", "debug=true")
+
+	runCommand(t, newRootCmd().root, "eval_badtag",
+		"eval", "-t", "replicas=three")
+}
+
+func TestBuildTags(t *testing.T) {
+	runCommand(t, newRootCmd().root, "eval_notags", "eval")
+
+	runCommand(t, newRootCmd().root, "eval_prod", "eval", "--tags", "staging,prod")
+}
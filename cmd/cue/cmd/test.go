@@ -0,0 +1,205 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/load"
+	"github.com/kylelemons/godebug/diff"
+	"github.com/spf13/cobra"
+)
+
+const (
+	testSection = "test"
+
+	flagRun flagName = "run"
+)
+
+func newTestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test [packages]",
+		Short: "run tests defined in test files",
+		Long: `test evaluates the tests of the named packages.
+
+Tests are defined in test files, which are regular CUE files within the
+same package with a filename ending in _test.cue. Test files are unified
+with the other files of the package. Each field of the top-level test
+struct defines a test:
+
+	test <Name>: {
+		// in is the value under test. If in is not specified, the
+		// test itself is the value under test.
+		in?: _
+
+		// out, if specified, is the expected value of in.
+		out?: _
+	}
+
+A test passes if the value under test evaluates to a concrete value
+without errors and, if out is specified, this value equals out.
+Packages without a test struct are reported as having no tests.
+
+Example:
+
+	$ cat <<EOF > schema.cue
+	package schema
+
+	Service: {
+		name: string
+		port: *80 | int
+	}
+	EOF
+
+	$ cat <<EOF > schema_test.cue
+	package schema
+
+	test defaultPort: {
+		in:  Service & {name: "web"}
+		out: {name: "web", port: 80}
+	}
+	EOF
+
+	$ cue test
+	--- PASS: defaultPort
+	ok  	.
+`,
+		RunE: runTest,
+	}
+
+	cmd.Flags().String(string(flagRun), "",
+		"run only those tests matching the regular expression")
+
+	return cmd
+}
+
+func runTest(cmd *cobra.Command, args []string) error {
+	log.SetOutput(cmd.OutOrStderr())
+
+	var match *regexp.Regexp
+	if expr := flagRun.String(cmd); expr != "" {
+		var err error
+		if match, err = regexp.Compile(expr); err != nil {
+			return err
+		}
+	}
+
+	binst := load.Instances(args, &load.Config{
+		Tests:     true,
+		BuildTags: buildTags(cmd),
+	})
+
+	w := cmd.OutOrStdout()
+	failed := false
+	for _, b := range binst {
+		for _, f := range b.TestCUEFiles {
+			if err := b.AddFile(b.Abs(f), nil); err != nil {
+				return err
+			}
+		}
+		inst := cue.Build([]*build.Instance{b})[0]
+		exitIfErr(cmd, inst, inst.Err, true)
+
+		tests := inst.Lookup(testSection)
+		if !tests.Exists() {
+			fmt.Fprintf(w, "?   \t%s\t[no tests]\n", b.DisplayPath)
+			continue
+		}
+
+		ok := true
+		iter, err := tests.Fields()
+		exitIfErr(cmd, inst, err, true)
+		for iter.Next() {
+			name := iter.Label()
+			if match != nil && !match.MatchString(name) {
+				continue
+			}
+			buf := &bytes.Buffer{}
+			if runOneTest(buf, iter.Value()) {
+				fmt.Fprintf(w, "--- PASS: %s\n", name)
+			} else {
+				ok = false
+				fmt.Fprintf(w, "--- FAIL: %s\n", name)
+				writeIndented(w, buf.String())
+			}
+		}
+
+		if ok {
+			fmt.Fprintf(w, "ok  \t%s\n", b.DisplayPath)
+		} else {
+			failed = true
+			fmt.Fprintf(w, "FAIL\t%s\n", b.DisplayPath)
+		}
+	}
+	if failed {
+		exit()
+	}
+	return nil
+}
+
+// runOneTest runs test v and reports whether it passes. Failures are
+// reported to w.
+func runOneTest(w io.Writer, v cue.Value) bool {
+	got := v
+	if in := v.Lookup("in"); in.Exists() {
+		got = in
+	}
+	if err := got.Validate(cue.Concrete(true)); err != nil {
+		printError(w, err)
+		return false
+	}
+
+	want := v.Lookup("out")
+	if !want.Exists() || equalValues(got, want) {
+		return true
+	}
+	fmt.Fprintln(w, "unexpected value (-want +got):")
+	fmt.Fprint(w, diff.Diff(formatValue(want), formatValue(got)))
+	fmt.Fprintln(w)
+	return false
+}
+
+// equalValues reports whether a and b evaluate to the same concrete value,
+// taking defaults into account.
+func equalValues(a, b cue.Value) bool {
+	var x, y interface{}
+	if a.Decode(&x) != nil || b.Decode(&y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+func formatValue(v cue.Value) string {
+	syn := v.Syntax(cue.Concrete(true))
+	b, err := format.Node(syn, format.UseSpaces(4), format.TabIndent(false))
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+func writeIndented(w io.Writer, s string) {
+	for _, line := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+		fmt.Fprintf(w, "    %s\n", line)
+	}
+}
@@ -0,0 +1,4 @@
+package buildtags
+
+env: string
+replicas: *1 | int
@@ -0,0 +1,5 @@
+// +build !prod
+
+package buildtags
+
+env: "dev"
@@ -0,0 +1,6 @@
+// +build prod
+
+package buildtags
+
+env: "prod"
+replicas: 5
@@ -75,6 +75,45 @@ const (
 	High
 )
 
+// Phase is the state of a run.
+// +enum
+type Phase string
+
+const (
+	// Pending means the run has not started.
+	Pending Phase = "Pending"
+	Running Phase = "Running"
+	Done    Phase = "Done"
+)
+
+// Limits is validated.
+type Limits struct {
+	Name     string   `json:"name" validate:"required,min=1,max=20"`
+	Replicas int      `json:"replicas,omitempty" validate:"required,gte=1,lt=10"`
+	Mode     string   `json:"mode" validate:"oneof=fast slow"`
+	Tags     []string `json:"tags" validate:"max=5,dive,min=1"`
+	Comment  string   `json:"comment,omitempty" validate:"omitempty,min=3"`
+	Email    string   `json:"email" validate:"email"`
+
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:ExclusiveMinimum=true
+	// +kubebuilder:validation:Maximum=100
+	Ratio *float64 `json:"ratio,omitempty"`
+
+	// +kubebuilder:validation:Pattern=`^[a-z]+$`
+	// +kubebuilder:validation:Enum=alpha;beta
+	Kind string `json:"kind"`
+
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:Optional
+	Ports []Port `json:"ports"`
+}
+
+// Port is a port number.
+// +kubebuilder:validation:Minimum=1
+// +kubebuilder:validation:Maximum=65535
+type Port int
+
 type CustomJSON struct {
 }
 
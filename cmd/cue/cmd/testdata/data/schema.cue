@@ -0,0 +1,13 @@
+package data
+
+deployment <Name>: {
+	kind:     "Deployment"
+	name:     Name
+	replicas: *1 | int
+}
+
+service <Name>: {
+	kind: "Service"
+	name: Name
+	port: *80 | int
+}
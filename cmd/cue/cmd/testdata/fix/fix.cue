@@ -0,0 +1,18 @@
+package fix
+
+deployment <Name>: {
+	replicas: *1 | int
+}
+
+service <Name>: {
+	name: Name
+}
+
+deployment frontend: {
+	replicas: 2
+}
+
+frontendReplicas: deployment.frontend.replicas
+defaultReplicas:  replicas
+
+replicas: 1
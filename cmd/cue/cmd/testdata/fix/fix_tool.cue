@@ -0,0 +1,13 @@
+package fix
+
+command deploy: {
+	task echo: {
+		kind:   "exec"
+		cmd:    "echo \(defaultReplicas)"
+		stdout: string
+	}
+	task print: {
+		kind: "print"
+		text: task.echo.stdout
+	}
+}
@@ -0,0 +1,3 @@
+package fmt
+
+formatted: 1
@@ -0,0 +1,5 @@
+package fmt
+
+a: {b:1}
+list: [1,2,
+3]
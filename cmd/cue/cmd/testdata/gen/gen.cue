@@ -0,0 +1,38 @@
+package gen
+
+// Phase is the state of a run.
+Phase: "Pending" | "Running" | "Done"
+
+// Port is a port number.
+Port: int & >=1 & <=65535
+
+// Service describes a network service.
+Service: {
+	// name identifies the service.
+	name:      =~"^[a-z]+$"
+	port:      Port
+	phase?:    Phase
+	protocol:  *"TCP" | "UDP"
+	replicas?: int & >=1
+	weight:    number
+	tags: [...string]
+	labels <_>: string
+	secure: bool
+	data?:  bytes
+
+	// owner is null for unowned services.
+	owner: null | {
+		name:   string
+		email?: string
+	}
+	endpoints: [...{
+		host: string
+		path: string | *"/"
+	}]
+	extra?: _
+}
+
+// defaults is not converted, as its name starts with a lowercase letter.
+defaults: {
+	protocol: "TCP"
+}
@@ -6,6 +6,7 @@ package pkg1
 
 import (
 	p2 "cuelang.org/go/cmd/cue/cmd/testdata/code/go/pkg2"
+	"strings"
 	"time"
 )
 
@@ -60,6 +61,48 @@ Low:     Level & 1
 Medium: Level & 2
 High:   Level & 3
 
+// Phase is the state of a run.
+// +enum
+Phase: enumPhase
+
+enumPhase:
+	Pending |
+	Running |
+	Done
+
+// Pending means the run has not started.
+Pending: Phase & "Pending"
+Running: Phase & "Running"
+Done:    Phase & "Done"
+
+// Limits is validated.
+Limits: {
+	name:     string & strings.MinRunes(1) & strings.MaxRunes(20) @go(Name)
+	replicas: int & >=1 & <10                                     @go(Replicas)
+	mode:     string & ("fast" | "slow")                          @go(Mode)
+	tags: [...string] @go(Tags,[]string)
+	comment?: string & ("" | strings.MinRunes(3)) @go(Comment)
+	email:    string                              @go(Email)
+
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:ExclusiveMinimum=true
+	// +kubebuilder:validation:Maximum=100
+	ratio?: null | float64 & >0 & <=100 @go(Ratio,*float64)
+
+	// +kubebuilder:validation:Pattern=`^[a-z]+$`
+	// +kubebuilder:validation:Enum=alpha;beta
+	kind: string & =~"^[a-z]+$" & ("alpha" | "beta") @go(Kind)
+
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:Optional
+	ports?: [...Port] & [_, ...] @go(Ports,[]Port)
+}
+
+// Port is a port number.
+// +kubebuilder:validation:Minimum=1
+// +kubebuilder:validation:Maximum=65535
+Port: int & >=1 & <=65535
+
 CustomJSON: _
 
 CustomYAML: {
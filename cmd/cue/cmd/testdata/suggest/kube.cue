@@ -0,0 +1,50 @@
+package suggest
+
+deployment bartender: {
+	kind:       "Deployment"
+	apiVersion: "apps/v1"
+	metadata name: "bartender"
+	metadata labels component: "frontend"
+	spec replicas: 1
+	spec template metadata labels app: "bartender"
+	spec template spec containers: [{image: "gcr.io/myproj/bartender:v0.1.34"}]
+}
+
+deployment host: {
+	kind:       "Deployment"
+	apiVersion: "apps/v1"
+	metadata name: "host"
+	metadata labels component: "frontend"
+	spec replicas: 2
+	spec template metadata labels app: "host"
+	spec template spec containers: [{image: "gcr.io/myproj/host:v0.1.10"}]
+}
+
+deployment waiter: {
+	kind:       "Deployment"
+	apiVersion: "apps/v1"
+	metadata name: "waiter"
+	metadata labels component: "frontend"
+	spec replicas: 1
+	spec template metadata labels app: "waiter"
+	spec template spec containers: [{image: "gcr.io/myproj/waiter:v0.3.0"}]
+}
+
+service <Name>: {
+	kind: "Service"
+	metadata name: Name
+}
+
+service bartender: {
+	kind: "Service"
+	metadata name: "bartender"
+	metadata labels component: "frontend"
+	spec ports: [{port: 7080}]
+}
+
+service host: {
+	kind: "Service"
+	metadata name: "host"
+	metadata labels component: "frontend"
+	spec ports: [{port: 7080}]
+}
@@ -0,0 +1,9 @@
+package tags
+
+env:      *"dev" | "prod" @tag(env)
+replicas: int @tag(replicas)
+debug:    bool | *false @tag(debug)
+server: {
+	port: int | *8080 @tag(port)
+}
+msg: "running \(env) with \(replicas) replicas"
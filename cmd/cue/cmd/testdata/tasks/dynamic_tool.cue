@@ -0,0 +1,23 @@
+package home
+
+import "strings"
+
+// Tasks generated from the results of other tasks.
+command dynamic: {
+	task list: {
+		kind:   "exec"
+		cmd:    "echo prod"
+		stdout: string
+	}
+	task: {
+		"deploy-\(c)": {
+			kind: "print"
+			text: "deploying to \(c)"
+		} for c in strings.Fields(command.dynamic.task.list.stdout)
+
+		staging: {
+			kind: "print"
+			text: "deploying to staging"
+		} if strings.Contains(command.dynamic.task.list.stdout, "staging")
+	}
+}
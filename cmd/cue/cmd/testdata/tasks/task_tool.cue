@@ -48,3 +48,15 @@ command http: {
 		text: task.http.response.body
 	}
 }
+
+command finally: {
+	task bad: {
+		kind:   "exec"
+		cmd:    "ls --badflags"
+		stderr: string // suppress error message
+	}
+	finally cleanup: {
+		kind: "print"
+		text: "cleaning up"
+	}
+}
@@ -0,0 +1,7 @@
+package schema
+
+Service: {
+	name: string
+	port: *80 | int
+	host: "\(name).example.com"
+}
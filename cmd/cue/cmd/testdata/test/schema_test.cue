@@ -0,0 +1,17 @@
+package schema
+
+test defaultPort: {
+	in: Service & {name: "web"}
+	out: {name: "web", port: 80, host: "web.example.com"}
+}
+
+test customPort: Service & {name: "db", port: 5432}
+
+test wrongHost: {
+	in: Service & {name: "api"}
+	out: {name: "api", port: 80, host: "api.example.org"}
+}
+
+test incomplete: Service & {port: 8080}
+
+test badPort: Service & {name: "x", port: "80"}
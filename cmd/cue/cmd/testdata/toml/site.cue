@@ -0,0 +1,18 @@
+package site
+
+baseURL:  "https://example.org/"
+title:    "My Site"
+paginate: 10
+
+params: {
+	author: "Jane"
+	tags: ["go", "cue"]
+}
+
+menu main: [{
+	name:   "Home"
+	weight: 1
+}, {
+	name:   "Blog"
+	weight: 2
+}]
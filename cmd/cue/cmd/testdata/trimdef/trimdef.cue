@@ -0,0 +1,34 @@
+package trimdef
+
+Base: {
+	kind:     "Deployment"
+	replicas: 1
+}
+
+deploy web: Base & {
+	kind:     "Deployment"
+	replicas: 1
+	name:     "web"
+}
+
+svc <Name>: *{
+	port: 80
+	type: "ClusterIP"
+} | {
+	port: int
+	type: "NodePort"
+}
+
+svc a: {
+	port: 80
+	type: "ClusterIP"
+}
+
+svc b: {
+	port: 8080
+	type: "NodePort"
+}
+
+svc c: {
+	port: 80
+}
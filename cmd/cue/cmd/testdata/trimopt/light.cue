@@ -0,0 +1,21 @@
+package light
+
+light <Name>: {
+	room: string
+	off:  0
+	on:   *100 | int
+	dim?: int
+}
+
+light ceiling50: {
+	room: "MasterBedroom"
+	on:   100
+	off:  0
+	dim?: int
+}
+
+light l2: {
+	room: "Kitchen"
+	on:   100
+	off:  0
+}
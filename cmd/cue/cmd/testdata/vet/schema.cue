@@ -0,0 +1,11 @@
+package vet
+
+Deployment: {
+	kind:     "Deployment"
+	name:     string
+	replicas: >=1 & <=10
+}
+
+deployment <Name>: Deployment & {
+	name: Name
+}
@@ -0,0 +1,11 @@
+package vetdata
+
+kind:     "Deployment"
+name:     string
+replicas: >=1 & <=10
+
+// Service is incomplete, but is not part of any document.
+Service: {
+	name: string
+	port: int
+}
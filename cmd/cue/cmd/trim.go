@@ -19,12 +19,15 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/load"
 	"cuelang.org/go/cue/token"
 	"cuelang.org/go/internal"
 	"github.com/spf13/cobra"
@@ -32,7 +35,6 @@ import (
 
 // TODO:
 // - remove the limitations mentioned in the documentation
-// - implement verification post-processing as extra safety
 
 // newTrimCmd creates a trim command
 func newTrimCmd() *cobra.Command {
@@ -42,16 +44,32 @@ func newTrimCmd() *cobra.Command {
 		Long: `trim removes fields from structs that are already defined by a template
 
 A field, struct, or list is removed if it is implied by a template, a list type
-value, a comprehension or any other implied content. It will modify the files
-in place.
+value, a comprehension, a struct it is unified with, as in Def & {...}, or any
+other implied content. It will modify the files in place.
 
 Limitations
 Removal is on a best effort basis. Some caveats:
 - Fields in implied content may refer to fields within the struct in which
   they are included, but are only resolved on a best-effort basis.
-- Disjunctions that contain structs in implied content cannot be used to
-  remove fields.
-- There is currently no verification step: manual verification is required.
+- Disjunctions that contain structs in implied content can only be used to
+  remove fields if the value selects the default disjunct.
+- Optional fields are never removed, nor do they cause other fields to be
+  removed, as they do not contribute to the output.
+
+After removal, the trimmed files are evaluated again and compared against
+the original evaluation. If the results differ, trim reports an error and
+no files are modified.
+
+The --dryrun flag prints the changes that would be made as unified diffs
+instead of modifying the files.
+
+The --suggest flag proposes templates instead of removing fields. For each
+struct of which all values are structs, such as a collection of
+deployments, it computes the fields that have the same value in all of
+them, as well as string fields that equal the label of the value, which
+are defined in terms of the template label. It then prints the template,
+together with the reduction in fields it would achieve. Fields already
+implied by existing templates are not included. No files are modified.
 
 Examples:
 
@@ -88,26 +106,30 @@ removal.
 	}
 
 	flagOut.Add(cmd)
+	cmd.Flags().BoolP(string(flagDryrun), "n", false,
+		"only print the changes")
+	cmd.Flags().Bool(string(flagSuggest), false,
+		"suggest templates for structs of similar values")
 	return cmd
 }
 
 func runTrim(cmd *cobra.Command, args []string) error {
-	// TODO: Do something more fine-grained. Optional fields are mostly not
-	// useful to consider as an optional field will almost never subsume
-	// another value. However, an optional field may subsume and therefore
-	// trigger the removal of another optional field.
-	// For now this is the better approach: trimming is not 100% accurate,
-	// and optional fields are just more likely to cause edge cases that may
-	// block a removal.
-	internal.DropOptional = true
-	defer func() { internal.DropOptional = false }()
-
 	binst := loadFromArgs(cmd, args)
 	if binst == nil {
 		return nil
 	}
 	instances := buildInstances(cmd, binst)
 
+	if flagSuggest.Bool(cmd) {
+		for _, inst := range instances {
+			a := suggestTemplates(nil, inst.Value(), nil)
+			if err := writeSuggestions(cmd.OutOrStdout(), a); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
 	// dst := flagName("o").String(cmd)
 	dst := flagOut.String(cmd)
 	if dst != "" && dst != "-" {
@@ -119,6 +141,13 @@ func runTrim(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	opts := []format.Option{}
+	if flagSimplify.Bool(cmd) {
+		opts = append(opts, format.Simplify())
+	}
+
+	var files []trimmedFile
+	overlay := map[string]load.Source{}
 	for i, inst := range binst {
 		gen := newTrimSet(cmd)
 		for _, f := range inst.Files {
@@ -128,44 +157,133 @@ func runTrim(cmd *cobra.Command, args []string) error {
 		root := instances[i].Lookup()
 		rm := gen.trim("root", root, cue.Value{}, root)
 
-		if flagDryrun.Bool(cmd) {
-			continue
-		}
-
 		for _, f := range inst.Files {
-			filename := f.Filename
-
 			f.Decls = gen.trimDecls(f.Decls, rm, root, true)
 
-			opts := []format.Option{}
-			if flagSimplify.Bool(cmd) {
-				opts = append(opts, format.Simplify())
-			}
-
 			b, err := format.Node(f, opts...)
 			if err != nil {
 				return fmt.Errorf("error formatting file: %v", err)
 			}
+			files = append(files, trimmedFile{f.Filename, b})
 
-			if dst == "-" {
-				_, err := cmd.OutOrStdout().Write(b)
-				if err != nil {
-					return err
-				}
-				continue
-			} else if dst != "" {
-				filename = dst
+			abs, err := filepath.Abs(f.Filename)
+			if err != nil {
+				return err
 			}
+			overlay[abs] = load.FromBytes(b)
+		}
+	}
+
+	exitOnErr(cmd, verifyTrim(cmd, args, overlay), true)
+
+	for _, f := range files {
+		filename := f.filename
+
+		switch {
+		case flagDryrun.Bool(cmd):
+			src, err := ioutil.ReadFile(filename)
+			if err != nil {
+				return err
+			}
+			name := relFilename(filename)
+			writeDiff(cmd.OutOrStdout(), name, name, src, f.src)
+			continue
 
-			err = ioutil.WriteFile(filename, b, 0644)
+		case dst == "-":
+			_, err := cmd.OutOrStdout().Write(f.src)
 			if err != nil {
 				return err
 			}
+			continue
+
+		case dst != "":
+			filename = dst
+		}
+
+		err := ioutil.WriteFile(filename, f.src, 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A trimmedFile holds the formatted result of trimming a file.
+type trimmedFile struct {
+	filename string
+	src      []byte
+}
+
+// verifyTrim reports an error if any of the instances for the given
+// arguments evaluates differently after replacing its files with the trimmed
+// versions in overlay.
+func verifyTrim(cmd *cobra.Command, args []string, overlay map[string]load.Source) error {
+	before := load.Instances(args, &load.Config{BuildTags: buildTags(cmd)})
+	after := load.Instances(args, &load.Config{
+		BuildTags: buildTags(cmd),
+		Overlay:   overlay,
+	})
+	if len(before) != len(after) {
+		return errors.Newf(token.NoPos, "trim: verification failed: could not reload instances")
+	}
+
+	// Build all instances at once so that their values can be compared.
+	instances := cue.Build(append(before, after...))
+	for i, b := range instances[:len(before)] {
+		a := instances[len(before)+i]
+		if a.Err != nil {
+			return errors.Wrapf(a.Err, token.NoPos,
+				"trim: verification failed for %s", b.Dir)
+		}
+		if !sameValue(b.Lookup(), a.Lookup()) {
+			return errors.Newf(token.NoPos,
+				"trim: verification failed for %s: trimmed result differs from original; no files were modified", b.Dir)
 		}
 	}
 	return nil
 }
 
+// sameValue reports whether v and w evaluate to the same value, including
+// hidden and optional fields. Values with a default are compared by their
+// default, as trim may remove a field that equals the default of implied
+// content.
+func sameValue(v, w cue.Value) bool {
+	v, _ = v.Default()
+	w, _ = w.Default()
+	if v.Kind() != w.Kind() || v.IncompleteKind() != w.IncompleteKind() {
+		return false
+	}
+	switch v.Kind() {
+	case cue.StructKind:
+		fields := map[key]cue.Value{}
+		optional := map[key]bool{}
+		for iter, _ := v.Fields(cue.All()); iter.Next(); {
+			fields[iterKey(iter)] = iter.Value()
+			optional[iterKey(iter)] = iter.IsOptional()
+		}
+		n := 0
+		for iter, _ := w.Fields(cue.All()); iter.Next(); n++ {
+			x, ok := fields[iterKey(iter)]
+			if !ok || optional[iterKey(iter)] != iter.IsOptional() ||
+				!sameValue(x, iter.Value()) {
+				return false
+			}
+		}
+		return n == len(fields)
+
+	case cue.ListKind:
+		a, _ := v.List()
+		b, _ := w.List()
+		for a.Next() {
+			if !b.Next() || !sameValue(a.Value(), b.Value()) {
+				return false
+			}
+		}
+		return !b.Next()
+	}
+	return v.Subsumes(w) && w.Subsumes(v)
+}
+
 type trimSet struct {
 	cmd       *cobra.Command
 	stack     []string
@@ -219,6 +337,18 @@ func (t *trimSet) markNodes(n ast.Node) {
 
 		case *ast.ListComprehension, *ast.ComprehensionDecl:
 			t.markAlwaysGen(x, true)
+
+		case *ast.BinaryExpr:
+			// A struct that is unified with a reference to another struct,
+			// as in Def & {...}, embeds the referenced struct. Its fields
+			// are implied content for the unified struct.
+			if x.Op == token.AND {
+				for _, y := range []ast.Expr{x.X, x.Y} {
+					if id, ok := y.(*ast.Ident); ok && id.Node != nil {
+						t.markAlwaysGen(id.Node, false)
+					}
+				}
+			}
 		}
 	})
 }
@@ -258,6 +388,22 @@ func isDisjunctionOfStruct(n ast.Node) bool {
 	return false
 }
 
+// structOperands returns the struct literals unified by x.
+func structOperands(x *ast.BinaryExpr) (a []*ast.StructLit) {
+	if x.Op != token.AND {
+		return nil
+	}
+	for _, y := range []ast.Expr{x.X, x.Y} {
+		switch y := y.(type) {
+		case *ast.StructLit:
+			a = append(a, y)
+		case *ast.BinaryExpr:
+			a = append(a, structOperands(y)...)
+		}
+	}
+	return a
+}
+
 func hasStruct(n ast.Node) bool {
 	hasStruct := false
 	ast.Walk(n, func(n ast.Node) bool {
@@ -288,7 +434,11 @@ func hasStruct(n ast.Node) bool {
 // - As the parallel structure is different, it may resolve to different
 //   default values. There is no support yet for selecting defaults of a value
 //   based on another value without doing a full unification. So for now we
-//   skip any disjunction containing structs.
+//   only use the default of a disjunction containing structs, and only if it
+//   unifies with the value. Removing fields then cannot cause another
+//   disjunct to be selected.
+// - Structs that are unified with a reference to another struct, as in
+//   Def & {...}, are treated as implied content of the unified struct.
 //
 //		v      the current value
 //		m      the "mixed-in" values
@@ -301,13 +451,18 @@ func (t *trimSet) trim(label string, v, m, scope cue.Value) (rmSet []ast.Node) {
 
 	vSplit := v.Split()
 
-	// At the moment disjunctions of structs are note supported. Detect them and
-	// punt.
-	// TODO: support disjunctions.
+	// For disjunctions of structs, only the default can be used to remove
+	// fields, and only if the default is consistent with the value. Otherwise
+	// removing fields may cause a different disjunct to be selected. Punt if
+	// this is not the case.
 	mSplit := m.Split()
-	for _, v := range mSplit {
-		if isDisjunctionOfStruct(v.Source()) {
-			return
+	for i, w := range mSplit {
+		if isDisjunctionOfStruct(w.Source()) {
+			d, ok := w.Default()
+			if !ok || d.Unify(v).Err() != nil {
+				return
+			}
+			mSplit[i] = d
 		}
 	}
 
@@ -356,6 +511,12 @@ func (t *trimSet) trim(label string, v, m, scope cue.Value) (rmSet []ast.Node) {
 		// Process fields.
 		rm := []ast.Node{}
 		for iter, _ := v.Fields(cue.All()); iter.Next(); {
+			if iter.IsOptional() {
+				// Optional fields do not contribute to the output. Removing
+				// them may affect the constraints imposed on a future
+				// field, so they are always kept.
+				continue
+			}
 			mSub := valueMap[iterKey(iter)]
 			if fn != nil {
 				mSub = mSub.Unify(fn(iter.Label()))
@@ -388,6 +549,13 @@ func (t *trimSet) trim(label string, v, m, scope cue.Value) (rmSet []ast.Node) {
 						rmSet = append(rmSet, src)
 					}
 
+				case *ast.BinaryExpr:
+					// Remove fields from the structs unified with
+					// embedded structs.
+					for _, s := range structOperands(x) {
+						s.Elts = t.trimDecls(s.Elts, rm, m, canRemove)
+					}
+
 				default:
 					if len(t.stack) == 1 {
 						// TODO: fix this hack to pass down the fields to remove
@@ -22,4 +22,12 @@ func TestTrim(t *testing.T) {
 	cmd := newTrimCmd()
 	cmd.ParseFlags([]string{"-o", "-"})
 	runCommand(t, cmd, "trim")
+
+	cmd = newTrimCmd()
+	cmd.ParseFlags([]string{"--dryrun"})
+	runCommand(t, cmd, "trim_dryrun")
+
+	cmd = newTrimCmd()
+	cmd.ParseFlags([]string{"--suggest"})
+	runCommand(t, cmd, "trim_suggest")
 }
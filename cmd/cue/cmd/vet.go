@@ -15,36 +15,81 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
 	"github.com/spf13/cobra"
 	"golang.org/x/text/message"
 )
 
 func newVetCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "vet",
+		Use:   "vet [packages] [data files]",
 		Short: "validate CUE configurations",
-		RunE:  doVet,
+		Long: `vet validates CUE and other data files.
+
+By default it will only validate if there are no errors.
+The -c validates that all regular fields are concrete.
+
+Arguments with a JSON or YAML extension are taken to be data files, which
+are validated against the CUE instances given by the other arguments.
+Each document of a data file, such as each object of a JSON stream or
+each document of a multi-document YAML file, is validated separately.
+By default, a document is unified with the top-level value of the
+instance. The -d flag selects a value of the instance, such as a
+definition, to unify the documents with instead. The -l flag places a
+document at the given path in the instance before validation. It
+accepts the same label expressions as the --path flag of cue import.
+The label expressions are evaluated within the scope of the document,
+or, with --with-context, within a struct with the fields filename,
+index, recordCount, and data, the latter holding the document.
+As for CUE instances, incomplete values, such as fields of a schema that
+are missing from a document, are only reported as errors with -c. Without
+-d or -l, only the fields of a document are validated; the other fields
+of the instance are taken to be part of the schema.
+
+Errors for a document give the path of the offending value relative to
+the document, and identify the document by the name of the file and its
+index, starting at 0, within the file, as in data.yaml[2].
+
+By default errors are reported in a human-readable format. The --errors
+flag selects a machine-readable format instead: json writes a JSON object
+for each error on a separate line, and sarif writes a single SARIF 2.1.0
+log, suitable for tools such as code scanners. In both cases the errors
+are written to standard error and include the message, the path to the
+offending value, and the source positions that contributed to the error.
+
+Examples:
+
+	$ cue vet schema.cue data/*.yaml -d Deployment
+
+	$ cue vet ./schema deploy.yaml -l 'deployment: "\(metadata.name)"'
+`,
+		RunE: withErrorReport(doVet),
 	}
 
 	cmd.Flags().BoolP(string(flagConcrete), "c", false,
 		"require the evaluation to be concrete")
+	cmd.Flags().StringP(string(flagSchema), "d", "",
+		"expression to select the schema for data files")
+	addDataFlags(cmd)
+	addErrorsFlag(cmd)
 
 	return cmd
 }
 
+const flagSchema flagName = "schema"
+
 func doVet(cmd *cobra.Command, args []string) error {
+	args, files := splitDataFiles(args)
 	instances := buildFromArgs(cmd, args)
-
-	var exprs []ast.Expr
-	for _, e := range flagExpression.StringArray(cmd) {
-		expr, err := parser.ParseExpr("<expression flag>", e)
-		if err != nil {
-			return err
-		}
-		exprs = append(exprs, expr)
+	if len(files) > 0 {
+		return vetFiles(cmd, instances, files)
 	}
 
 	shown := false
@@ -71,11 +116,194 @@ func doVet(cmd *cobra.Command, args []string) error {
 			err = inst.Value().Validate(append(opt, cue.Concrete(false))...)
 			if !shown && err == nil {
 				shown = true
-				p := message.NewPrinter(getLang())
-				p.Fprintln(w, "some instances are incomplete; use the -c flag to show errors or suppress this message")
+				printIncomplete(w)
 			}
 		}
 		exitIfErr(cmd, inst, err, false)
 	}
 	return nil
 }
+
+// vetFiles validates each document in the given data files against each of
+// the given instances.
+func vetFiles(cmd *cobra.Command, instances []*cue.Instance, files []string) error {
+	var schemaExpr ast.Expr
+	if s := flagSchema.String(cmd); s != "" {
+		expr, err := parser.ParseExpr("<schema flag>", s)
+		if err != nil {
+			return err
+		}
+		schemaExpr = expr
+	}
+
+	p, err := newDataPlacer(cmd)
+	if err != nil {
+		return err
+	}
+
+	concrete := true
+	hasFlag := false
+	if flag := cmd.Flag(string(flagConcrete)); flag != nil && flag.Changed {
+		hasFlag = true
+		concrete = flagConcrete.Bool(cmd)
+	}
+
+	docs := map[string][]ast.Expr{}
+	for _, filename := range files {
+		objs, err := readDataFile(cmd, filename)
+		if err != nil {
+			return err
+		}
+		docs[filename] = objs
+	}
+
+	failed := false
+	shown := false
+	for _, inst := range instances {
+		schema := inst.Value()
+		if schemaExpr != nil {
+			schema = inst.Eval(schemaExpr)
+			exitIfErr(cmd, inst, schema.Err(), true)
+		}
+
+		for _, filename := range files {
+			objs := docs[filename]
+			for i, doc := range objs {
+				expr, labels, err := p.place(filename, i, len(objs), doc)
+				if err != nil {
+					failed = true
+					exitIfErr(cmd, inst, err, false)
+					continue
+				}
+				d := &vetDoc{filename: filename, index: i}
+				for _, l := range labels {
+					name, _ := ast.LabelName(l)
+					d.prefix = append(d.prefix, name)
+				}
+				// A document unified with the top level of the instance only
+				// covers its own fields: the other fields belong to the schema.
+				if schemaExpr == nil && len(labels) == 0 {
+					d.fields = docFields(doc)
+				}
+				// Evaluate the data within the instance so that both use
+				// the same label index.
+				v := schema.Unify(inst.Eval(expr))
+				errs := d.validate(v, concrete)
+				if errs != nil && !hasFlag && d.validate(v, false) == nil {
+					if !shown {
+						shown = true
+						printIncomplete(cmd.OutOrStderr())
+					}
+					continue
+				}
+				for _, e := range errs {
+					failed = true
+					exitIfErr(cmd, inst, e, false)
+				}
+			}
+		}
+	}
+	if failed {
+		exit()
+	}
+	return nil
+}
+
+// A vetDoc identifies a document of a data file and the part of the value
+// it was unified with that belongs to it.
+type vetDoc struct {
+	filename string
+	index    int
+	prefix   []string        // path at which the document was placed
+	fields   map[string]bool // if not nil, the fields of the document
+}
+
+// validate validates the part of v that belongs to d and returns its errors
+// with paths relative to the document. Errors lose their path when
+// validating a value that is not the root, so it validates the root and
+// filters the errors instead.
+func (d *vetDoc) validate(v cue.Value, concrete bool) (errs []errors.Error) {
+	err := v.Validate(
+		cue.Attributes(true),
+		cue.Optional(true),
+		cue.Hidden(true),
+		cue.Concrete(concrete),
+	)
+	for _, e := range errors.Errors(err) {
+		path := errors.Path(e)
+		if !hasPrefix(path, d.prefix) {
+			continue
+		}
+		if path != nil {
+			path = path[len(d.prefix):]
+			if d.fields != nil && (len(path) == 0 || !d.fields[path[0]]) {
+				continue
+			}
+		}
+		errs = append(errs, &docError{e, path, d.filename, d.index})
+	}
+	return errs
+}
+
+// docFields returns the labels of the fields of a document, or nil if the
+// document is not a struct.
+func docFields(doc ast.Expr) map[string]bool {
+	s, ok := doc.(*ast.StructLit)
+	if !ok {
+		return nil
+	}
+	fields := map[string]bool{}
+	for _, d := range s.Elts {
+		if f, ok := d.(*ast.Field); ok {
+			if name, ok := ast.LabelName(f.Label); ok {
+				fields[name] = true
+			}
+		}
+	}
+	return fields
+}
+
+// printIncomplete reports that incomplete values were not treated as errors.
+func printIncomplete(w io.Writer) {
+	p := message.NewPrinter(getLang())
+	p.Fprintln(w, "some instances are incomplete; use the -c flag to show errors or suppress this message")
+}
+
+// hasPrefix reports whether path starts with prefix. An error without a path
+// is assumed to apply to any prefix.
+func hasPrefix(path, prefix []string) bool {
+	if path == nil {
+		return true
+	}
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i, s := range prefix {
+		if path[i] != s {
+			return false
+		}
+	}
+	return true
+}
+
+// A docError is an error for a document of a data file. Its path is
+// relative to the document.
+type docError struct {
+	err      errors.Error
+	path     []string
+	filename string
+	index    int
+}
+
+func (e *docError) Path() []string              { return e.path }
+func (e *docError) Position() token.Pos         { return e.err.Position() }
+func (e *docError) InputPositions() []token.Pos { return e.err.InputPositions() }
+
+func (e *docError) Msg() (string, []interface{}) {
+	format, args := e.err.Msg()
+	return "%s[%d]: " + format, append([]interface{}{e.filename, e.index}, args...)
+}
+
+func (e *docError) Error() string {
+	return fmt.Sprintf("%s[%d]: %v", e.filename, e.index, e.err.Error())
+}
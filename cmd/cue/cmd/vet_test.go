@@ -0,0 +1,54 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import "testing"
+
+func TestVet(t *testing.T) {
+	files := []string{
+		"testdata/vet/deployments.yaml",
+		"testdata/vet/deployments.jsonl",
+	}
+
+	cmd := newVetCmd()
+	cmd.ParseFlags([]string{"-d", "Deployment"})
+	runCommand(t, cmd, "vet_schema", files...)
+
+	cmd = newVetCmd()
+	cmd.ParseFlags([]string{"-c", "-d", "Deployment"})
+	runCommand(t, cmd, "vet_concrete", files...)
+
+	cmd = newVetCmd()
+	cmd.ParseFlags([]string{"-l", `"deployment" "\(name)"`})
+	runCommand(t, cmd, "vet_path", files...)
+
+	cmd = newVetCmd()
+	cmd.ParseFlags([]string{"--with-context", "-l", `"deployment" "\(data.name)"`})
+	runCommand(t, cmd, "vet_context", files...)
+
+	cmd = newVetCmd()
+	cmd.ParseFlags([]string{"-d", "Deployment", "--errors", "sarif"})
+	runCommand(t, cmd, "vet_sarif", files...)
+}
+
+func TestVetData(t *testing.T) {
+	const file = "testdata/vetdata/data.yaml"
+
+	runCommand(t, newVetCmd(), "vet_data", file)
+
+	cmd := newVetCmd()
+	cmd.ParseFlags([]string{"-c"})
+	runCommand(t, cmd, "vet_data_concrete", file)
+}
@@ -170,6 +170,15 @@ func TestBuiltins(t *testing.T) {
 	}, {
 		test("encoding/json", `json.MarshalStream([{a: 1}, {b: 2}])`),
 		`"{\"a\":1}\n{\"b\":2}\n"`,
+	}, {
+		test("encoding/toml", `toml.Marshal({a: 1, b: {c: "x"}})`),
+		`"a = 1\n\n[b]\nc = \"x\"\n"`,
+	}, {
+		test("encoding/toml", `toml.Unmarshal("a = 1\n[b]\nc = 'x'\n")`),
+		`{a: 1, b: {c: "x"}}`,
+	}, {
+		test("encoding/toml", `toml.Unmarshal("a = inf")`),
+		`_|_(error in call to encoding/toml.Unmarshal: toml: inf cannot be represented in CUE)`,
 	}, {
 		test("encoding/yaml", `yaml.MarshalStream([{a: 1}, {b: 2}])`),
 		`"a: 1\n---\nb: 2\n"`,
@@ -190,7 +199,16 @@ func TestBuiltins(t *testing.T) {
 		`"e"`,
 	}, {
 		test("strings", `strings.MinRunes(0) & "e"`),
-		`_|_(invalid value "e" (does not satisfy strings.MinRunes(0)))`,
+		`"e"`,
+	}, {
+		test("strings", `strings.MinRunes(2) & "e"`),
+		`_|_(invalid value "e" (does not satisfy strings.MinRunes(2)))`,
+	}, {
+		test("list", `list.MinItems([1, 2], 2)`), `true`,
+	}, {
+		test("list", `list.MinItems([1], 2)`), `false`,
+	}, {
+		test("list", `list.MaxItems([1, 2], 1)`), `false`,
 	}, {
 		test("math/bits", `bits.And(0x10000000000000F0E, 0xF0F7)`), `6`,
 	}, {
@@ -221,6 +239,47 @@ func TestBuiltins(t *testing.T) {
 	}, {
 		test("text/template", `template.Execute("{{.}}-{{.}}", "foo")`),
 		`"foo-foo"`,
+	}, {
+		test("time", `time.Duration() & "1h30m"`), `"1h30m"`,
+	}, {
+		test("time", `time.Duration() & "1x"`),
+		`_|_(error in call to time.Duration: time: unknown unit "x" in duration "1x")`,
+	}, {
+		test("time", `time.ParseDuration("1h30m")`), `5400000000000`,
+	}, {
+		test("time", `time.ParseDuration("1h30m") div time.Minute`), `90`,
+	}, {
+		test("time", `time.FormatDuration(90 * time.Second)`), `"1m30s"`,
+	}, {
+		test("time", `time.Format("2006-01-02") & "2019-10-28"`), `"2019-10-28"`,
+	}, {
+		test("time", `time.Format("2006-01-02") & "2019-10-32"`),
+		`_|_(error in call to time.Format: parsing time "2019-10-32": day out of range)`,
+	}, {
+		test("time", `time.FormatString(time.RFC1123, "2019-10-28T15:04:05Z")`),
+		`"Mon, 28 Oct 2019 15:04:05 UTC"`,
+	}, {
+		test("time", `time.Parse(time.RFC822Z, "28 Oct 19 15:04 +0100")`),
+		`"2019-10-28T14:04:00Z"`,
+	}, {
+		test("time", `time.Unix(1572275045, 500)`),
+		`"2019-10-28T15:04:05.0000005Z"`,
+	}, {
+		test("time", `time.After("2019-01-01T00:00:00Z") & "2019-10-28T15:04:05+01:00"`),
+		`"2019-10-28T15:04:05+01:00"`,
+	}, {
+		test("time", `time.Before("2019-01-01T00:00:00Z") & "2019-10-28T15:04:05Z"`),
+		`_|_(invalid value "2019-10-28T15:04:05Z" (does not satisfy time.Before("2019-01-01T00:00:00Z")))`,
+	}, {
+		// As strings, "1970-01-01T00:00:00.5Z" sorts before "1970-01-01T00:00:00Z".
+		test("time", `time.After(time.Unix(0, 0)) & time.Unix(0, 500000000)`),
+		`"1970-01-01T00:00:00.5Z"`,
+	}, {
+		test("time", `time.Before(time.Unix(0, 0)) & time.Unix(0, 500000000)`),
+		`_|_(invalid value "1970-01-01T00:00:00.5Z" (does not satisfy time.Before("1970-01-01T00:00:00Z")))`,
+	}, {
+		test("time", `time.Before("1970-01-01T01:00:00.5+01:00") & time.Unix(0, 0)`),
+		`"1970-01-01T00:00:00Z"`,
 	}}
 	for _, tc := range testCases {
 		t.Run("", func(t *testing.T) {
@@ -24,10 +24,13 @@ import (
 	"strings"
 	"text/tabwriter"
 	"text/template"
+	"time"
 	"unicode"
 
+	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/literal"
 	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/internal/encoding/toml"
 	"cuelang.org/go/internal/third_party/yaml"
 	"github.com/cockroachdb/apd/v2"
 	goyaml "github.com/ghodss/yaml"
@@ -394,6 +397,42 @@ var builtinPackages = map[string]*builtinPkg{
 			},
 		}},
 	},
+	"encoding/toml": &builtinPkg{
+		native: []*builtin{{
+			Name:   "Marshal",
+			Params: []kind{topKind},
+			Result: stringKind,
+			Func: func(c *callCtxt) {
+				v := c.value(0)
+				c.ret, c.err = func() (interface{}, error) {
+					b, err := json.Marshal(v)
+					if err != nil {
+						return "", err
+					}
+					expr, err := parser.ParseExpr("", b)
+					if err != nil {
+						return "", err
+					}
+					b, err = toml.Marshal(expr)
+					return string(b), err
+				}()
+			},
+		}, {
+			Name:   "Unmarshal",
+			Params: []kind{stringKind},
+			Result: topKind,
+			Func: func(c *callCtxt) {
+				data := c.bytes(0)
+				c.ret, c.err = func() (interface{}, error) {
+					f, err := toml.Parse("", data)
+					if err != nil {
+						return nil, err
+					}
+					return &ast.StructLit{Elts: f.Decls}, nil
+				}()
+			},
+		}},
+	},
 	"encoding/yaml": &builtinPkg{
 		native: []*builtin{{
 			Name:   "Marshal",
@@ -475,7 +514,7 @@ var builtinPackages = map[string]*builtinPkg{
 			Func: func(c *callCtxt) {
 				a, n := c.list(0), c.int(1)
 				c.ret = func() interface{} {
-					return len(a) <= n
+					return len(a) >= n
 				}()
 			},
 		}, {
@@ -1567,10 +1606,10 @@ var builtinPackages = map[string]*builtinPkg{
 			Params: []kind{stringKind, intKind},
 			Result: boolKind,
 			Func: func(c *callCtxt) {
-				s, max := c.string(0), c.int(1)
+				s, min := c.string(0), c.int(1)
 				c.ret = func() interface{} {
 
-					return len([]rune(s)) <= max
+					return len([]rune(s)) >= min
 				}()
 			},
 		}, {
@@ -1981,7 +2020,182 @@ var builtinPackages = map[string]*builtinPkg{
 		}},
 	},
 	"time": &builtinPkg{
-		native: []*builtin{{}},
+		native: []*builtin{{
+			Name:  "ANSIC",
+			Const: "\"Mon Jan _2 15:04:05 2006\"",
+		}, {
+			Name:  "UnixDate",
+			Const: "\"Mon Jan _2 15:04:05 MST 2006\"",
+		}, {
+			Name:  "RubyDate",
+			Const: "\"Mon Jan 02 15:04:05 -0700 2006\"",
+		}, {
+			Name:  "RFC822",
+			Const: "\"02 Jan 06 15:04 MST\"",
+		}, {
+			Name:  "RFC822Z",
+			Const: "\"02 Jan 06 15:04 -0700\"",
+		}, {
+			Name:  "RFC850",
+			Const: "\"Monday, 02-Jan-06 15:04:05 MST\"",
+		}, {
+			Name:  "RFC1123",
+			Const: "\"Mon, 02 Jan 2006 15:04:05 MST\"",
+		}, {
+			Name:  "RFC1123Z",
+			Const: "\"Mon, 02 Jan 2006 15:04:05 -0700\"",
+		}, {
+			Name:  "RFC3339",
+			Const: "\"2006-01-02T15:04:05Z07:00\"",
+		}, {
+			Name:  "RFC3339Nano",
+			Const: "\"2006-01-02T15:04:05.999999999Z07:00\"",
+		}, {
+			Name:  "Kitchen",
+			Const: "\"3:04PM\"",
+		}, {
+			Name:  "Nanosecond",
+			Const: "1",
+		}, {
+			Name:  "Microsecond",
+			Const: "1000",
+		}, {
+			Name:  "Millisecond",
+			Const: "1000000",
+		}, {
+			Name:  "Second",
+			Const: "1000000000",
+		}, {
+			Name:  "Minute",
+			Const: "60000000000",
+		}, {
+			Name:  "Hour",
+			Const: "3600000000000",
+		}, {
+			Name:   "Duration",
+			Params: []kind{stringKind},
+			Result: boolKind,
+			Func: func(c *callCtxt) {
+				s := c.string(0)
+				c.ret, c.err = func() (interface{}, error) {
+					if _, err := time.ParseDuration(s); err != nil {
+						return false, err
+					}
+					return true, nil
+				}()
+			},
+		}, {
+			Name:   "ParseDuration",
+			Params: []kind{stringKind},
+			Result: intKind,
+			Func: func(c *callCtxt) {
+				s := c.string(0)
+				c.ret, c.err = func() (interface{}, error) {
+					d, err := time.ParseDuration(s)
+					if err != nil {
+						return 0, err
+					}
+					return int64(d), nil
+				}()
+			},
+		}, {
+			Name:   "FormatDuration",
+			Params: []kind{intKind},
+			Result: stringKind,
+			Func: func(c *callCtxt) {
+				d := c.int64(0)
+				c.ret = func() interface{} {
+					return time.Duration(d).String()
+				}()
+			},
+		}, {
+			Name:   "Format",
+			Params: []kind{stringKind, stringKind},
+			Result: boolKind,
+			Func: func(c *callCtxt) {
+				value, layout := c.string(0), c.string(1)
+				c.ret, c.err = func() (interface{}, error) {
+					if _, err := time.Parse(layout, value); err != nil {
+						return false, err
+					}
+					return true, nil
+				}()
+			},
+		}, {
+			Name:   "FormatString",
+			Params: []kind{stringKind, stringKind},
+			Result: stringKind,
+			Func: func(c *callCtxt) {
+				layout, value := c.string(0), c.string(1)
+				c.ret, c.err = func() (interface{}, error) {
+					t, err := time.Parse(time.RFC3339Nano, value)
+					if err != nil {
+						return "", err
+					}
+					return t.Format(layout), nil
+				}()
+			},
+		}, {
+			Name:   "Parse",
+			Params: []kind{stringKind, stringKind},
+			Result: stringKind,
+			Func: func(c *callCtxt) {
+				layout, value := c.string(0), c.string(1)
+				c.ret, c.err = func() (interface{}, error) {
+					t, err := time.Parse(layout, value)
+					if err != nil {
+						return "", err
+					}
+					return t.UTC().Format(time.RFC3339Nano), nil
+				}()
+			},
+		}, {
+			Name:   "Unix",
+			Params: []kind{intKind, intKind},
+			Result: stringKind,
+			Func: func(c *callCtxt) {
+				sec, nsec := c.int64(0), c.int64(1)
+				c.ret = func() interface{} {
+					return time.Unix(sec, nsec).UTC().Format(time.RFC3339Nano)
+				}()
+			},
+		}, {
+			Name:   "Before",
+			Params: []kind{stringKind, stringKind},
+			Result: boolKind,
+			Func: func(c *callCtxt) {
+				value, t := c.string(0), c.string(1)
+				c.ret, c.err = func() (interface{}, error) {
+					a, err := time.Parse(time.RFC3339Nano, value)
+					if err != nil {
+						return false, err
+					}
+					b, err := time.Parse(time.RFC3339Nano, t)
+					if err != nil {
+						return false, err
+					}
+					return a.Before(b), nil
+				}()
+			},
+		}, {
+			Name:   "After",
+			Params: []kind{stringKind, stringKind},
+			Result: boolKind,
+			Func: func(c *callCtxt) {
+				value, t := c.string(0), c.string(1)
+				c.ret, c.err = func() (interface{}, error) {
+					a, err := time.Parse(time.RFC3339Nano, value)
+					if err != nil {
+						return false, err
+					}
+					b, err := time.Parse(time.RFC3339Nano, t)
+					if err != nil {
+						return false, err
+					}
+					return a.After(b), nil
+				}()
+			},
+		}},
 		cue: `{
 	Time: null | =~"^\("\\d{4}-(0[1-9]|1[0-2])-(0[1-9]|[1-2]\\d|3[0-1])")T\("([0-1]\\d|2[0-3]):[0-5]\\d:[0-5]\\d")\("(.\\d{1,10})?")\("(Z|(-|\\+)\\d\\d:\\d\\d)")$"
 }`,
@@ -0,0 +1,671 @@
+// Copyright 2018 The CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"github.com/cockroachdb/apd/v2"
+)
+
+// Decode initializes x with Value v. If x is a struct, it will validate the
+// constraints specified in the field tags.
+//
+// Decode walks v directly, rather than converting it to JSON first, using the
+// following rules:
+//
+//   - Struct fields are matched against the labels of v using the same names
+//     as are used for converting Go values to CUE: the name given by a json,
+//     yaml, or protobuf tag, or the field name otherwise. Like encoding/json,
+//     Decode prefers an exact match, but also accepts a case-insensitive
+//     match. Fields of embedded structs are treated as if they were fields of
+//     the outer struct.
+//   - A field with a cue tag is unified with the constraints of the tag before
+//     it is decoded. The constraints may refer to other fields of the struct.
+//   - Types implementing json.Unmarshaler or encoding.TextUnmarshaler decode
+//     themselves. TextUnmarshalers accept strings, bytes, and numbers.
+//   - big.Int and apd.Decimal values are decoded without loss of precision.
+//   - A []byte is decoded from bytes or, as in encoding/json, from a
+//     base64-encoded string.
+//   - A cue.Value is set to the value itself, even if it is incomplete.
+//   - An empty interface is set to nil, bool, int64 (or *big.Int if the value
+//     does not fit), float64, string, []byte, []interface{}, or
+//     map[string]interface{}.
+//   - Slices and maps are replaced with newly allocated ones; existing map
+//     entries are retained.
+//   - null sets pointers, interfaces, maps, and slices to nil and leaves other
+//     values unchanged.
+//
+// Decode reports an error for any value that cannot be represented by the
+// corresponding Go value, including incomplete values. The error includes the
+// path to the offending value. Except for values reached through pointers
+// already present in x, x is not modified if an error is reported.
+func (v Value) Decode(x interface{}) error {
+	rv := reflect.ValueOf(x)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &decodeError{errors.Newf(token.NoPos,
+			"cannot decode into non-pointer or nil value of type %T", x)}
+	}
+	// Decode into a copy so that x is left unmodified in case of an error.
+	tmp := reflect.New(rv.Type().Elem())
+	tmp.Elem().Set(rv.Elem())
+	if err := v.decode(tmp); err != nil {
+		return err
+	}
+	rv.Elem().Set(tmp.Elem())
+	return nil
+}
+
+var _ errors.Error = &decodeError{}
+
+type decodeError struct {
+	err errors.Error
+}
+
+func decodeErrf(v Value, src source, msg string, args ...interface{}) error {
+	arguments := append([]interface{}{msg}, args...)
+	return &decodeError{v.toErr(v.idx.mkErr(src, arguments...))}
+}
+
+func (e *decodeError) Error() string {
+	path := e.Path()
+	if len(path) == 0 {
+		return fmt.Sprintf("cue: decode error: %v", e.err)
+	}
+	p := strings.Join(path, ".")
+	return fmt.Sprintf("cue: decode error at path %s: %v", p, e.err)
+}
+
+func (e *decodeError) Path() []string               { return e.err.Path() }
+func (e *decodeError) Msg() (string, []interface{}) { return e.err.Msg() }
+func (e *decodeError) Position() token.Pos          { return e.err.Position() }
+func (e *decodeError) InputPositions() []token.Pos {
+	return e.err.InputPositions()
+}
+
+var (
+	valueType       = reflect.TypeOf(Value{})
+	bigIntType      = reflect.TypeOf(big.Int{})
+	decimalType     = reflect.TypeOf(apd.Decimal{})
+	emptyInterface  = reflect.TypeOf((*interface{})(nil)).Elem()
+	jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// decode sets rv to v.
+func (v Value) decode(rv reflect.Value) error {
+	if v.path == nil {
+		// Like an undefined pointer, the zero Value is interpreted as null.
+		v = newValueRoot(v.idx.newContext(), &nullLit{})
+	}
+	if rv.Type() == valueType && rv.CanSet() {
+		rv.Set(reflect.ValueOf(v))
+		return nil
+	}
+
+	v, _ = v.Default()
+	ctx := v.ctx()
+	x := v.eval(ctx)
+	k := x.kind()
+	switch {
+	case k == bottomKind:
+		return &decodeError{v.toErr(x.(*bottom))}
+	case k.hasReferences():
+		return decodeErrf(v, x, "value %q contains unresolved references", ctx.str(x))
+	case !k.isGround():
+		return decodeErrf(v, x, "cannot convert incomplete value %q to Go value of type %s",
+			ctx.str(x), indirectType(rv.Type()))
+	}
+
+	u, rv := indirect(rv, k == nullKind)
+	if u != nil {
+		return v.unmarshal(u, x)
+	}
+
+	if k == nullKind {
+		switch rv.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+			rv.Set(reflect.Zero(rv.Type()))
+		}
+		return nil
+	}
+
+	switch t := rv.Type(); t {
+	case valueType:
+		rv.Set(reflect.ValueOf(v))
+		return nil
+
+	case bigIntType:
+		if _, err := v.Int(rv.Addr().Interface().(*big.Int)); err != nil {
+			return v.typeErr(x, t)
+		}
+		return nil
+
+	case decimalType:
+		n, ok := x.(*numLit)
+		if !ok {
+			return v.typeErr(x, t)
+		}
+		rv.Addr().Interface().(*apd.Decimal).Set(&n.v)
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Interface:
+		if rv.NumMethod() > 0 {
+			return decodeErrf(v, x, "cannot decode into non-empty interface type %s", rv.Type())
+		}
+		return v.decodeInterface(rv, x)
+
+	case reflect.Bool:
+		b, ok := x.(*boolLit)
+		if !ok {
+			return v.typeErr(x, rv.Type())
+		}
+		rv.SetBool(b.b)
+
+	case reflect.String:
+		s, ok := x.(*stringLit)
+		if !ok {
+			return v.typeErr(x, rv.Type())
+		}
+		rv.SetString(s.str)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if k != intKind {
+			return v.typeErr(x, rv.Type())
+		}
+		i, err := v.Int64()
+		if err != nil || rv.OverflowInt(i) {
+			return v.overflowErr(x, rv.Type())
+		}
+		rv.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if k != intKind {
+			return v.typeErr(x, rv.Type())
+		}
+		i, err := v.Uint64()
+		if err != nil || rv.OverflowUint(i) {
+			return v.overflowErr(x, rv.Type())
+		}
+		rv.SetUint(i)
+
+	case reflect.Float32, reflect.Float64:
+		if !k.isAnyOf(numKind) {
+			return v.typeErr(x, rv.Type())
+		}
+		f, err := v.Float64()
+		if err != nil || rv.OverflowFloat(f) {
+			return v.overflowErr(x, rv.Type())
+		}
+		rv.SetFloat(f)
+
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			switch x := x.(type) {
+			case *bytesLit:
+				rv.SetBytes(append([]byte(nil), x.b...))
+				return nil
+			case *stringLit:
+				// Like encoding/json, a string holds base64-encoded bytes.
+				b, err := base64.StdEncoding.DecodeString(x.str)
+				if err != nil {
+					return decodeErrf(v, x, "cannot decode string into Go value of type %s: %v", rv.Type(), err)
+				}
+				rv.SetBytes(b)
+				return nil
+			}
+		}
+		if k != listKind {
+			return v.typeErr(x, rv.Type())
+		}
+		iter, err := v.List()
+		if err != nil {
+			return &decodeError{errors.Promote(err, "")}
+		}
+		s := reflect.MakeSlice(rv.Type(), iter.len, iter.len)
+		for i := 0; iter.Next(); i++ {
+			if err := iter.Value().decode(s.Index(i)); err != nil {
+				return err
+			}
+		}
+		rv.Set(s)
+
+	case reflect.Array:
+		if k != listKind {
+			return v.typeErr(x, rv.Type())
+		}
+		iter, err := v.List()
+		if err != nil {
+			return &decodeError{errors.Promote(err, "")}
+		}
+		// Like encoding/json, drop extra elements and zero missing ones.
+		i := 0
+		for ; i < rv.Len() && iter.Next(); i++ {
+			if err := iter.Value().decode(rv.Index(i)); err != nil {
+				return err
+			}
+		}
+		for ; i < rv.Len(); i++ {
+			rv.Index(i).Set(reflect.Zero(rv.Type().Elem()))
+		}
+
+	case reflect.Map:
+		if k != structKind {
+			return v.typeErr(x, rv.Type())
+		}
+		return v.decodeMap(rv, x)
+
+	case reflect.Struct:
+		if k != structKind {
+			return v.typeErr(x, rv.Type())
+		}
+		return v.decodeStruct(rv)
+
+	default:
+		return decodeErrf(v, x, "cannot decode into unsupported type %s", rv.Type())
+	}
+	return nil
+}
+
+func (v Value) typeErr(x value, t reflect.Type) error {
+	return decodeErrf(v, x, "cannot unmarshal %s into Go value of type %s", x.kind(), t)
+}
+
+func (v Value) overflowErr(x value, t reflect.Type) error {
+	return decodeErrf(v, x, "value %s overflows Go value of type %s", v.ctx().str(x), t)
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// indirect follows and allocates pointers as needed, until it reaches a
+// non-pointer value or a value implementing one of the unmarshaler
+// interfaces. If isNull is true, it stops at the last pointer so that it can
+// be set to nil.
+//
+// This mirrors the function of the same name in encoding/json.
+func indirect(rv reflect.Value, isNull bool) (unmarshaler interface{}, v reflect.Value) {
+	// If rv is a named type and is addressable, start with its address, so
+	// that if the type has pointer methods, we find them.
+	if rv.Kind() != reflect.Ptr && rv.Type().Name() != "" && rv.CanAddr() {
+		rv = rv.Addr()
+	}
+	for {
+		// Load value from interface, but only if the result will be usefully
+		// addressable.
+		if rv.Kind() == reflect.Interface && !rv.IsNil() {
+			e := rv.Elem()
+			if e.Kind() == reflect.Ptr && !e.IsNil() && (!isNull || e.Elem().Kind() == reflect.Ptr) {
+				rv = e
+				continue
+			}
+		}
+
+		if rv.Kind() != reflect.Ptr {
+			break
+		}
+
+		if isNull && rv.CanSet() {
+			break
+		}
+
+		// Prevent infinite loops if v is an interface pointing to itself.
+		if rv.Elem().Kind() == reflect.Interface && rv.Elem().Elem() == rv {
+			rv = rv.Elem()
+			break
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+
+		switch t := rv.Type(); {
+		case t.Elem() == bigIntType || t.Elem() == decimalType:
+			// Decode these directly to retain precision.
+		case t.Implements(jsonUnmarshaler), t.Implements(textUnmarshaler):
+			return rv.Interface(), reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+	return nil, rv
+}
+
+// unmarshal decodes v into a value implementing json.Unmarshaler or
+// encoding.TextUnmarshaler.
+func (v Value) unmarshal(u interface{}, x value) error {
+	if u, ok := u.(json.Unmarshaler); ok {
+		b, err := v.marshalJSON()
+		if err != nil {
+			return &decodeError{unwrapJSONError(err)}
+		}
+		if err := u.UnmarshalJSON(b); err != nil {
+			return decodeErrf(v, x, "%v", err)
+		}
+		return nil
+	}
+
+	var b []byte
+	switch x := x.(type) {
+	case *stringLit:
+		b = []byte(x.str)
+	case *bytesLit:
+		b = x.b
+	case *numLit:
+		b = []byte(x.v.String())
+	default:
+		return v.typeErr(x, reflect.TypeOf(u).Elem())
+	}
+	if err := u.(encoding.TextUnmarshaler).UnmarshalText(b); err != nil {
+		return decodeErrf(v, x, "%v", err)
+	}
+	return nil
+}
+
+// decodeInterface sets the empty interface rv to the Go value that most
+// naturally represents x.
+func (v Value) decodeInterface(rv reflect.Value, x value) error {
+	var r interface{}
+	switch x := x.(type) {
+	case *boolLit:
+		r = x.b
+
+	case *stringLit:
+		r = x.str
+
+	case *bytesLit:
+		r = append([]byte(nil), x.b...)
+
+	case *numLit:
+		if x.k == intKind {
+			if i, err := v.Int64(); err == nil {
+				r = i
+			} else {
+				r, _ = v.Int(nil)
+			}
+		} else {
+			f, err := v.Float64()
+			if err != nil {
+				return v.overflowErr(x, rv.Type())
+			}
+			r = f
+		}
+
+	case *list:
+		var a []interface{}
+		if err := v.decode(reflect.ValueOf(&a)); err != nil {
+			return err
+		}
+		if a == nil {
+			a = []interface{}{}
+		}
+		r = a
+
+	case *structLit:
+		m := map[string]interface{}{}
+		if err := v.decode(reflect.ValueOf(&m)); err != nil {
+			return err
+		}
+		r = m
+
+	default:
+		return v.typeErr(x, rv.Type())
+	}
+	rv.Set(reflect.ValueOf(r))
+	return nil
+}
+
+func (v Value) decodeMap(rv reflect.Value, x value) error {
+	t := rv.Type()
+	switch kt := t.Key(); {
+	case kt.Kind() == reflect.String,
+		reflect.PtrTo(kt).Implements(textUnmarshaler):
+	default:
+		switch kt.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		default:
+			return decodeErrf(v, x, "cannot decode into map with key type %s", kt)
+		}
+	}
+
+	iter, err := v.Fields()
+	if err != nil {
+		return &decodeError{errors.Promote(err, "")}
+	}
+	// Add to a copy of the map so that it is left unmodified in case of an
+	// error.
+	m := reflect.MakeMap(t)
+	for _, k := range rv.MapKeys() {
+		m.SetMapIndex(k, rv.MapIndex(k))
+	}
+	for iter.Next() {
+		elem := reflect.New(t.Elem()).Elem()
+		if err := iter.Value().decode(elem); err != nil {
+			return err
+		}
+		key, err := mapKey(t.Key(), iter.Label())
+		if err != nil {
+			return decodeErrf(iter.Value(), x, "invalid map key %q: %v", iter.Label(), err)
+		}
+		m.SetMapIndex(key, elem)
+	}
+	rv.Set(m)
+	return nil
+}
+
+func mapKey(t reflect.Type, label string) (reflect.Value, error) {
+	if reflect.PtrTo(t).Implements(textUnmarshaler) {
+		key := reflect.New(t)
+		err := key.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(label))
+		return key.Elem(), err
+	}
+	key := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		key.SetString(label)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(label, 10, 64)
+		if err != nil || key.OverflowInt(i) {
+			return key, fmt.Errorf("not a valid %s", t)
+		}
+		key.SetInt(i)
+	default:
+		i, err := strconv.ParseUint(label, 10, 64)
+		if err != nil || key.OverflowUint(i) {
+			return key, fmt.Errorf("not a valid %s", t)
+		}
+		key.SetUint(i)
+	}
+	return key, nil
+}
+
+func (v Value) decodeStruct(rv reflect.Value) error {
+	ctx := v.ctx()
+	obj, err := v.structVal(ctx)
+	if err != nil {
+		return &decodeError{v.toErr(err)}
+	}
+	fields := goFields(rv.Type())
+	for i := range obj.n.arcs {
+		label, fv := obj.At(i)
+		f := fields.lookup(label)
+		if f == nil {
+			continue
+		}
+		if f.tag != "" {
+			tag := parseTag(ctx, obj.n, obj.n.arcs[i].feature, f.tag)
+			fv = remakeValue(fv, mkBin(ctx, token.NoPos, opUnify, fv.path.v, tag))
+		}
+		dst, ok := fieldByIndex(rv, f.index)
+		if !ok {
+			return decodeErrf(fv, obj.n, "cannot set embedded pointer to unexported struct type for field %s", f.name)
+		}
+		if err := fv.decode(dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldByIndex returns the field of struct rv with the given index,
+// allocating embedded pointers as needed.
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				if !rv.CanSet() {
+					return rv, false
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, true
+}
+
+// A goField describes a field of a Go struct, possibly of an embedded struct.
+type goField struct {
+	name   string
+	index  []int
+	tag    string // cue tag
+	tagged bool   // name given by a tag
+}
+
+type goFieldList []goField
+
+// lookup returns the field with the given name. Like encoding/json, it
+// prefers an exact match, but accepts a case-insensitive match.
+func (a goFieldList) lookup(name string) *goField {
+	var fold *goField
+	for i, f := range a {
+		if f.name == name {
+			return &a[i]
+		}
+		if fold == nil && strings.EqualFold(f.name, name) {
+			fold = &a[i]
+		}
+	}
+	return fold
+}
+
+var fieldCache sync.Map // map[reflect.Type]goFieldList
+
+// goFields returns the fields of struct type t that can be decoded into,
+// following the rules of encoding/json for embedded structs.
+func goFields(t reflect.Type) goFieldList {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.(goFieldList)
+	}
+
+	var all goFieldList
+	inPath := map[reflect.Type]bool{}
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		if inPath[t] {
+			return
+		}
+		inPath[t] = true
+		defer delete(inPath, t)
+
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := getName(&f)
+			if name == "-" {
+				continue
+			}
+			tagged := name != f.Name
+			index := append(index[:len(index):len(index)], i)
+			if f.Anonymous {
+				ft := indirectType(f.Type)
+				if !tagged && ft.Kind() == reflect.Struct {
+					walk(ft, index)
+					continue
+				}
+			}
+			if f.PkgPath != "" {
+				continue
+			}
+			all = append(all, goField{name, index, f.Tag.Get("cue"), tagged})
+		}
+	}
+	walk(t, nil)
+
+	// Of the fields with the same name, only keep the one with the shortest
+	// index. If there are several, keep the one with a tagged name, if any.
+	sort.SliceStable(all, func(i, j int) bool {
+		x, y := all[i], all[j]
+		switch {
+		case x.name != y.name:
+			return x.name < y.name
+		case len(x.index) != len(y.index):
+			return len(x.index) < len(y.index)
+		}
+		return x.tagged && !y.tagged
+	})
+	var fields goFieldList
+	for i := 0; i < len(all); {
+		j := i + 1
+		for j < len(all) && all[j].name == all[i].name {
+			j++
+		}
+		dominant := all[i]
+		if j > i+1 {
+			next := all[i+1]
+			if len(next.index) == len(dominant.index) && next.tagged == dominant.tagged {
+				// Ambiguous fields are ignored, just like in encoding/json.
+				i = j
+				continue
+			}
+		}
+		fields = append(fields, dominant)
+		i = j
+	}
+
+	// Restore the field order so that case-insensitive matches prefer the
+	// first field.
+	sort.Slice(fields, func(i, j int) bool {
+		return lessIndex(fields[i].index, fields[j].index)
+	})
+
+	f, _ := fieldCache.LoadOrStore(t, fields)
+	return f.(goFieldList)
+}
+
+func lessIndex(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
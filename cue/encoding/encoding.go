@@ -31,7 +31,7 @@ func (e *Encoding) Name() string {
 
 // All returns all known encodings.
 func All() []*Encoding {
-	return []*Encoding{jsonEnc, yamlEnc, protodefEnc}
+	return []*Encoding{jsonEnc, yamlEnc, protodefEnc, csvEnc, tsvEnc, tomlEnc}
 }
 
 // MapExtension returns the likely encoding for a given file extension.
@@ -43,6 +43,9 @@ var (
 	jsonEnc     = &Encoding{name: "json"}
 	yamlEnc     = &Encoding{name: "yaml"}
 	protodefEnc = &Encoding{name: "protobuf"}
+	csvEnc      = &Encoding{name: "csv"}
+	tsvEnc      = &Encoding{name: "tsv"}
+	tomlEnc     = &Encoding{name: "toml"}
 )
 
 // extensions maps a file extension to a Kind.
@@ -53,4 +56,7 @@ var extensions = map[string]*Encoding{
 	".yaml":   yamlEnc,
 	".yml":    yamlEnc,
 	".proto":  protodefEnc,
+	".csv":    csvEnc,
+	".tsv":    tsvEnc,
+	".toml":   tomlEnc,
 }
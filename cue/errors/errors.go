@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -418,6 +419,16 @@ type Config struct {
 
 	// ToSlash sets whether to use Unix paths. Mostly used for testing.
 	ToSlash bool
+
+	// ShowSource sets whether to print an excerpt of the source for each
+	// position of an error, with the offending token underlined. For errors
+	// with multiple positions, such as conflicting values, this shows each
+	// of the contributing values.
+	ShowSource bool
+
+	// ReadFile returns the contents of the named file. It is used to print
+	// source excerpts. If ReadFile is nil, ioutil.ReadFile is used.
+	ReadFile func(filename string) ([]byte, error)
 }
 
 // Print is a utility function that prints a list of errors to w,
@@ -428,8 +439,9 @@ func Print(w io.Writer, err error, cfg *Config) {
 	if cfg == nil {
 		cfg = &Config{}
 	}
+	src := &sources{cfg: cfg}
 	for _, e := range Errors(err) {
-		printError(w, e, cfg)
+		printError(w, e, cfg, src)
 	}
 }
 
@@ -437,7 +449,7 @@ func defaultFprintf(w io.Writer, format string, args ...interface{}) {
 	fmt.Fprintf(w, format, args...)
 }
 
-func printError(w io.Writer, err error, cfg *Config) {
+func printError(w io.Writer, err error, cfg *Config, src *sources) {
 	if err == nil {
 		return
 	}
@@ -474,6 +486,9 @@ func printError(w io.Writer, err error, cfg *Config) {
 			s = "-"
 		}
 		positions = append(positions, s)
+		if cfg.ShowSource && pos.IsValid() {
+			positions = append(positions, src.excerpt(pos)...)
+		}
 	}
 
 	if path := Path(err); path != nil {
@@ -490,3 +505,90 @@ func printError(w io.Writer, err error, cfg *Config) {
 		fprintf(w, "    %s\n", pos)
 	}
 }
+
+// sources caches the contents of files for printing source excerpts.
+type sources struct {
+	cfg   *Config
+	files map[string][]string
+}
+
+// excerpt returns the source line at pos followed by a line underlining the
+// token starting at pos. It returns nil if the source is not available.
+func (s *sources) excerpt(pos token.Position) []string {
+	lines := s.lines(pos.Filename)
+	if pos.Line < 1 || pos.Line > len(lines) {
+		return nil
+	}
+	line := lines[pos.Line-1]
+	col := pos.Column - 1
+	if col < 0 || col > len(line) {
+		return nil
+	}
+
+	// Preserve tabs so that the marker lines up with the source.
+	marker := &strings.Builder{}
+	for _, r := range line[:col] {
+		if r == '\t' {
+			marker.WriteByte('\t')
+		} else {
+			marker.WriteByte(' ')
+		}
+	}
+	marker.WriteByte('^')
+	marker.WriteString(strings.Repeat("~", tokenLen(line[col:])-1))
+
+	return []string{"    " + line, "    " + marker.String()}
+}
+
+func (s *sources) lines(filename string) []string {
+	if lines, ok := s.files[filename]; ok {
+		return lines
+	}
+	if s.files == nil {
+		s.files = map[string][]string{}
+	}
+	readFile := s.cfg.ReadFile
+	if readFile == nil {
+		readFile = ioutil.ReadFile
+	}
+	var lines []string
+	if b, err := readFile(filename); err == nil {
+		lines = strings.Split(strings.Replace(string(b), "\r\n", "\n", -1), "\n")
+	}
+	s.files[filename] = lines
+	return lines
+}
+
+// tokenLen returns an estimate of the length in bytes of the token at the
+// start of s. It recognizes identifiers, numbers, and quoted strings; any
+// other token is assumed to be a single character.
+func tokenLen(s string) int {
+	if s == "" {
+		return 1
+	}
+	switch q := s[0]; {
+	case q == '"' || q == '\'':
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case q:
+				return i + 1
+			}
+		}
+		return len(s)
+
+	case isWordChar(q):
+		i := 1
+		for i < len(s) && isWordChar(s[i]) {
+			i++
+		}
+		return i
+	}
+	return 1
+}
+
+func isWordChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' ||
+		'0' <= c && c <= '9' || c == '_' || c == '$' || c == '.'
+}
@@ -16,6 +16,7 @@ package errors
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"cuelang.org/go/cue/token"
@@ -190,3 +191,41 @@ func TestPrintError(t *testing.T) {
 		}
 	}
 }
+
+func TestPrintSource(t *testing.T) {
+	src := "a: {\n\tkind: \"Deployment\"\n}\na: kind: \"Service\"\n"
+	f := token.NewFile("test.cue", 0, len(src))
+	f.SetLinesForContent([]byte(src))
+	pos := func(s string) token.Pos {
+		return f.Pos(strings.Index(src, s), 0)
+	}
+
+	err := &posError{
+		pos:     pos(`"Deployment"`),
+		inputs:  []token.Pos{pos(`"Service"`)},
+		Message: NewMessage("conflicting values %q and %q", []interface{}{"Deployment", "Service"}),
+	}
+	cfg := &Config{
+		ShowSource: true,
+		ReadFile: func(filename string) ([]byte, error) {
+			if filename != "test.cue" {
+				t.Errorf("got filename %q; want test.cue", filename)
+			}
+			return []byte(src), nil
+		},
+	}
+
+	w := &bytes.Buffer{}
+	Print(w, err, cfg)
+	want := `conflicting values "Deployment" and "Service":
+    test.cue:2:8
+        	kind: "Deployment"
+        	      ^~~~~~~~~~~~
+    test.cue:4:10
+        a: kind: "Service"
+                 ^~~~~~~~~
+`
+	if got := w.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -50,6 +50,41 @@ func TabIndent(indent bool) Option {
 	return func(c *config) { c.TabIndent = indent }
 }
 
+// MaxLineWidth sets the maximum width of a line. Lists, structs, and
+// disjunctions that would exceed this width when printed on a single line
+// are wrapped over several lines. Tabs count as the tab width. A width of 0,
+// the default, disables wrapping.
+func MaxLineWidth(width int) Option {
+	return func(c *config) { c.maxWidth = width }
+}
+
+// SortFields sorts the fields of structs and files by label. Only runs of
+// consecutive regular fields are sorted; other declarations, such as
+// comprehensions, embeddings, and fields with dynamic labels, stay in place.
+func SortFields() Option {
+	return func(c *config) { c.sortFields = true }
+}
+
+// SortImports sorts the import specifications of each import declaration by
+// import path.
+func SortImports() Option {
+	return func(c *config) { c.sortImports = true }
+}
+
+// CollapseStructs specifies whether structs with a single field are printed
+// as a chain of labels, as in a: b: c: 1, or as nested struct literals, as
+// in a: {b: {c: 1}}. By default, the formatter follows the original source.
+// Structs with comments are never collapsed.
+func CollapseStructs(collapse bool) Option {
+	return func(c *config) {
+		if collapse {
+			c.structs = collapseStructs
+		} else {
+			c.structs = expandStructs
+		}
+	}
+}
+
 // TODO: other options:
 //
 // const (
@@ -105,9 +140,21 @@ type config struct {
 	Tabwidth  int // default: 4
 	Indent    int // default: 0 (all code is indented at least by this much)
 
-	simplify bool
+	simplify    bool
+	maxWidth    int
+	sortFields  bool
+	sortImports bool
+	structs     structMode
 }
 
+type structMode int
+
+const (
+	keepStructs structMode = iota
+	collapseStructs
+	expandStructs
+)
+
 func newConfig(opt []Option) *config {
 	cfg := &config{
 		Tabwidth:  8,
@@ -168,6 +215,10 @@ type formatter struct {
 	nestExpr int
 
 	labelBuf []labelEntry
+
+	// wrapped holds the operators of disjunctions that are broken over
+	// several lines to respect the maximum line width.
+	wrapped map[*ast.BinaryExpr]bool
 }
 
 type labelEntry struct {
@@ -178,6 +229,7 @@ type labelEntry struct {
 func newFormatter(p *printer) *formatter {
 	f := &formatter{
 		printer: p,
+		wrapped: map[*ast.BinaryExpr]bool{},
 		current: frame{
 			settings: settings{
 				nodeSep:   newline,
@@ -270,7 +322,8 @@ func (f *formatter) before(node ast.Node) bool {
 
 	if node != nil {
 		s, ok := node.(*ast.StructLit)
-		if ok && len(s.Elts) <= 1 && f.current.nodeSep != blank && f.onOneLine(node) {
+		if ok && len(s.Elts) <= 1 && f.current.nodeSep != blank &&
+			(f.onOneLine(node) || f.cfg.structs == expandStructs && !s.Lbrace.IsValid()) {
 			f.current.nodeSep = blank
 		}
 		f.current.cg = node.Comments()
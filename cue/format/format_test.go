@@ -423,3 +423,135 @@ func TestX(t *testing.T) {
 	_ = b
 	// t.Error("\n", string(b))
 }
+
+func TestOptions(t *testing.T) {
+	testCases := []struct {
+		desc string
+		opts []Option
+		in   string
+		out  string
+	}{{
+		desc: "wrap lists",
+		opts: []Option{MaxLineWidth(30)},
+		in: `a: [1, 2, 3]
+list: ["alpha", "beta", "gamma", "delta"]
+`,
+		out: `a: [1, 2, 3]
+list: [
+	"alpha",
+	"beta",
+	"gamma",
+	"delta",
+]
+`,
+	}, {
+		desc: "wrap structs",
+		opts: []Option{MaxLineWidth(30)},
+		in: `s: {a: 1, b: 2}
+t: {name: "name", value: "value"}
+`,
+		out: `s: {a: 1, b: 2}
+t: {
+	name:  "name"
+	value: "value"
+}
+`,
+	}, {
+		desc: "wrap disjunctions",
+		opts: []Option{MaxLineWidth(30)},
+		in: `a: "x" | "y"
+Kind: "Deployment" | "StatefulSet" | "Job"
+`,
+		out: `a:    "x" | "y"
+Kind: "Deployment" |
+	"StatefulSet" |
+	"Job"
+`,
+	}, {
+		desc: "wrap nested",
+		opts: []Option{MaxLineWidth(30)},
+		in: `a: {
+	b: [{a: 1, b: 2}, {a: 3, b: 4}, {a: 5}]
+}
+`,
+		out: `a: {
+	b: [
+		{a: 1, b: 2},
+		{a: 3, b: 4},
+		{a: 5},
+	]
+}
+`,
+	}, {
+		desc: "sort fields",
+		opts: []Option{SortFields()},
+		in: `c: 1
+a: {z: 1, y: 2}
+"b": 3
+d: [ x for x in a ]
+f: 4
+e: 5
+`,
+		out: `a: {y: 2, z: 1}
+"b": 3
+c:   1
+d:   [ x for x in a ]
+e:   5
+f:   4
+`,
+	}, {
+		desc: "sort imports",
+		opts: []Option{SortImports()},
+		in: `import (
+	"strings"
+	"encoding/json"
+)
+
+a: 1
+`,
+		out: `import (
+	"encoding/json"
+	"strings"
+)
+
+a: 1
+`,
+	}, {
+		desc: "collapse structs",
+		opts: []Option{CollapseStructs(true)},
+		in: `x: 1
+a: {b: {c: 1}}
+d: {
+	// comment
+	e: 1
+}
+`,
+		out: `x: 1
+a b c: 1
+d: {
+	// comment
+	e: 1
+}
+`,
+	}, {
+		desc: "expand structs",
+		opts: []Option{CollapseStructs(false)},
+		in: `z: 1
+a b c: 1
+`,
+		out: `z: 1
+a: {b: {c: 1}}
+`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			b, err := Source([]byte(tc.in), tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tc.out {
+				t.Errorf("\ngot:\n%s\nwant:\n%s", got, tc.out)
+			}
+		})
+	}
+}
@@ -15,7 +15,9 @@
 package format
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -39,7 +41,7 @@ func printNode(node interface{}, f *printer) error {
 	// case ast.Node: // TODO: do we need this?
 	// 	s.walk(x)
 	case []ast.Decl:
-		s.walkDeclList(x)
+		s.walkDeclList(x, false)
 	default:
 		goto unsupported
 	}
@@ -52,12 +54,23 @@ unsupported:
 
 // Helper functions for common node lists. They may be empty.
 
-func (f *formatter) walkDeclList(list []ast.Decl) {
+func (f *formatter) walkDeclList(list []ast.Decl, wrap bool) {
+	orig := list
+	if f.cfg.sortFields {
+		list = sortFields(list)
+	}
 	f.before(nil)
 	for i, x := range list {
 		if i > 0 {
 			f.print(declcomma)
 		}
+		switch {
+		case wrap:
+			f.print(newline, nooverride)
+		case x != orig[i]:
+			// Use the spacing of the original declaration at this position.
+			f.print(orig[i].Pos(), nooverride)
+		}
 		f.decl(x)
 		if j := i + 1; j < len(list) {
 			switch x := list[j].(type) {
@@ -67,7 +80,8 @@ func (f *formatter) walkDeclList(list []ast.Decl) {
 					// TODO: not entirely correct: could have multiple elements,
 					// not have a valid Lbrace, and be marked multiline. This
 					// cannot occur for ASTs resulting from a parse, though.
-					if x.Lbrace.IsValid() || len(x.Elts) != 1 {
+					if x.Lbrace.IsValid() || len(x.Elts) != 1 ||
+						f.cfg.structs == expandStructs {
 						f.print(f.formfeed())
 						continue
 					}
@@ -79,6 +93,9 @@ func (f *formatter) walkDeclList(list []ast.Decl) {
 		}
 		f.print(f.current.parentSep)
 	}
+	if wrap {
+		f.print(newline, nooverride)
+	}
 	f.after(nil)
 }
 
@@ -98,9 +115,12 @@ func (f *formatter) walkClauseList(list []ast.Clause) {
 	f.after(nil)
 }
 
-func (f *formatter) walkExprList(list []ast.Expr, depth int) {
+func (f *formatter) walkExprList(list []ast.Expr, depth int, wrap bool) {
 	f.before(nil)
 	for _, x := range list {
+		if wrap {
+			f.print(formfeed, nooverride)
+		}
 		f.before(x)
 		f.exprRaw(x, token.LowestPrec, depth)
 		f.print(comma, blank)
@@ -117,7 +137,7 @@ func (f *formatter) file(file *ast.File) {
 	}
 	f.current.pos = 3
 	f.visitComments(3)
-	f.walkDeclList(file.Decls)
+	f.walkDeclList(file.Decls, false)
 	f.after(file)
 	f.print(token.EOF)
 }
@@ -136,9 +156,14 @@ func (f *formatter) decl(decl ast.Decl) {
 		first, opt := n.Label, n.Optional != token.NoPos
 		// If the field has a valid position, we assume that an unspecified
 		// Lbrace does not signal the intend to collapse fields.
-		for n.Label.Pos().IsValid() || f.printer.cfg.simplify {
+		structs := f.printer.cfg.structs
+		for structs == collapseStructs ||
+			(structs == keepStructs && (n.Label.Pos().IsValid() || f.printer.cfg.simplify)) {
 			obj, ok := n.Value.(*ast.StructLit)
-			if !ok || len(obj.Elts) != 1 || (obj.Lbrace.IsValid() && !f.printer.cfg.simplify) {
+			if !ok || len(obj.Elts) != 1 {
+				break
+			}
+			if structs == keepStructs && obj.Lbrace.IsValid() && !f.printer.cfg.simplify {
 				break
 			}
 
@@ -229,17 +254,21 @@ func (f *formatter) decl(decl ast.Decl) {
 			f.print(blank, n.Lparen, token.LPAREN, n.Rparen, token.RPAREN, newline)
 			break
 		}
+		specs := n.Specs
+		if f.cfg.sortImports {
+			specs = sortImports(specs)
+		}
 		switch {
-		case len(n.Specs) == 1:
+		case len(specs) == 1:
 			if !n.Lparen.IsValid() {
 				f.print(blank)
-				f.walkSpecList(n.Specs)
+				f.walkSpecList(specs)
 				break
 			}
 			fallthrough
 		default:
 			f.print(blank, n.Lparen, token.LPAREN, newline, indent)
-			f.walkSpecList(n.Specs)
+			f.walkSpecList(specs)
 			f.print(unindent, newline, n.Rparen, token.RPAREN, newline)
 		}
 		f.print(newsection, nooverride)
@@ -314,7 +343,9 @@ func (f *formatter) label(l ast.Label, optional bool) {
 			s := n.Value
 			unquoted, err := strconv.Unquote(s)
 			if err == nil {
-				if isValidIdent(unquoted) {
+				// A label starting with an underscore would define a hidden
+				// field when unquoted.
+				if isValidIdent(unquoted) && !strings.HasPrefix(unquoted, "_") {
 					f.print(n.ValuePos, unquoted)
 					break
 				}
@@ -442,22 +473,32 @@ func (f *formatter) exprRaw(expr ast.Expr, prec1, depth int) {
 		}
 		wasIndented := f.possibleSelectorExpr(x.Fun, token.HighestPrec, depth)
 		f.print(x.Lparen, token.LPAREN)
-		f.walkExprList(x.Args, depth)
+		f.walkExprList(x.Args, depth, false)
 		f.print(trailcomma, noblank, x.Rparen, token.RPAREN)
 		if wasIndented {
 			f.print(unindent)
 		}
 
 	case *ast.StructLit:
+		wrap := f.exceedsWidth(x)
 		f.print(x.Lbrace, token.LBRACE, noblank, f.formfeed(), indent)
-		f.walkDeclList(x.Elts)
+		f.walkDeclList(x.Elts, wrap)
 		f.matchUnindent()
-		f.print(noblank, x.Rbrace, token.RBRACE)
+		rbrace := x.Rbrace
+		if !rbrace.IsValid() && f.current.nodeSep == blank {
+			// Keep structs without position information on a single line.
+			rbrace = token.NoSpace.Pos()
+		}
+		f.print(noblank, rbrace, token.RBRACE)
 
 	case *ast.ListLit:
+		wrap := f.exceedsWidth(x)
 		f.print(x.Lbrack, token.LBRACK, indent)
-		f.walkExprList(x.Elts, 1)
+		f.walkExprList(x.Elts, 1, wrap)
 		if x.Ellipsis != token.NoPos || x.Type != nil {
+			if wrap {
+				f.print(formfeed, nooverride)
+			}
 			f.print(x.Ellipsis, token.ELLIPSIS)
 			if x.Type != nil && !isTop(x.Type) {
 				f.expr(x.Type)
@@ -467,6 +508,9 @@ func (f *formatter) exprRaw(expr ast.Expr, prec1, depth int) {
 			f.current.pos += 2
 			f.visitComments(f.current.pos)
 		}
+		if wrap {
+			f.print(formfeed, nooverride)
+		}
 		f.matchUnindent()
 		f.print(noblank, x.Rbrack, token.RBRACK)
 
@@ -654,6 +698,18 @@ func (f *formatter) binaryExpr(x *ast.BinaryExpr, prec1, cutoff, depth int) {
 		return
 	}
 
+	if x.Op == token.OR && f.nestExpr == 1 && f.exceedsWidth(x) {
+		// Break the line after each operator of the disjunction.
+		for y := x; ; {
+			f.wrapped[y] = true
+			z, ok := y.X.(*ast.BinaryExpr)
+			if !ok || z.Op != token.OR {
+				break
+			}
+			y = z
+		}
+	}
+
 	printBlank := prec < cutoff
 
 	f.expr1(x.X, prec, depth+diffPrec(x.X, prec))
@@ -662,7 +718,10 @@ func (f *formatter) binaryExpr(x *ast.BinaryExpr, prec1, cutoff, depth int) {
 		f.print(blank)
 	}
 	f.print(x.OpPos, x.Op)
-	if x.Y.Pos().IsNewline() {
+	if f.wrapped[x] {
+		f.print(formfeed, nooverride)
+		printBlank = false
+	} else if x.Y.Pos().IsNewline() {
 		// at least one line break, but respect an extra empty line
 		// in the source
 		f.print(formfeed)
@@ -707,3 +766,61 @@ func isTop(e ast.Expr) bool {
 	ident, ok := e.(*ast.Ident)
 	return ok && ident.Name == "_"
 }
+
+// exceedsWidth reports whether x would exceed the maximum line width if it
+// were printed on a single line at the current position. It reports false
+// if x would not be printed on a single line in the first place.
+func (f *formatter) exceedsWidth(x ast.Expr) bool {
+	if f.cfg.maxWidth <= 0 {
+		return false
+	}
+	cfg := *f.cfg
+	cfg.maxWidth = 0
+	b, err := cfg.fprint(x)
+	if err != nil || bytes.IndexByte(b, '\n') >= 0 {
+		return false
+	}
+	return f.column()+len(b) > f.cfg.maxWidth
+}
+
+// sortFields returns a copy of list in which each run of consecutive fields
+// with a static label is sorted by label.
+func sortFields(list []ast.Decl) []ast.Decl {
+	list = append([]ast.Decl(nil), list...)
+	label := func(d ast.Decl) (string, bool) {
+		if f, ok := d.(*ast.Field); ok {
+			return ast.LabelName(f.Label)
+		}
+		return "", false
+	}
+	for i := 0; i < len(list); {
+		if _, ok := label(list[i]); !ok {
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(list) {
+			if _, ok := label(list[j]); !ok {
+				break
+			}
+			j++
+		}
+		run := list[i:j]
+		sort.SliceStable(run, func(a, b int) bool {
+			x, _ := label(run[a])
+			y, _ := label(run[b])
+			return x < y
+		})
+		i = j
+	}
+	return list
+}
+
+// sortImports returns a copy of specs sorted by import path.
+func sortImports(specs []*ast.ImportSpec) []*ast.ImportSpec {
+	specs = append([]*ast.ImportSpec(nil), specs...)
+	sort.SliceStable(specs, func(i, j int) bool {
+		return specs[i].Path.Value < specs[j].Path.Value
+	})
+	return specs
+}
@@ -39,6 +39,7 @@ type printer struct {
 
 	output      []byte
 	indent      int
+	lineIndent  int // number of tabs written at the start of the current line
 	spaceBefore bool
 }
 
@@ -320,6 +321,23 @@ func (p *printer) writeString(s string, isLit bool) {
 	}
 }
 
+// column returns an estimate of the visual column, starting at 0, at which
+// the next token is written, taking into account pending whitespace.
+func (p *printer) column() int {
+	if p.allowed&(newline|formfeed|newsection) != 0 {
+		n := p.cfg.Indent + p.indent
+		if p.allowed&indent != 0 {
+			n++
+		}
+		return n * p.cfg.Tabwidth
+	}
+	col := p.pos.Column - 1 + p.lineIndent*(p.cfg.Tabwidth-1)
+	if p.allowed&(blank|vtab) != 0 {
+		col++
+	}
+	return col
+}
+
 func (p *printer) writeByte(ch byte, n int) {
 	for i := 0; i < n; i++ {
 		p.output = append(p.output, ch)
@@ -335,6 +353,7 @@ func (p *printer) writeByte(ch byte, n int) {
 		for i := 0; i < n; i++ {
 			p.output = append(p.output, '\t')
 		}
+		p.lineIndent = n
 
 		// update positions
 		p.pos.Offset += n
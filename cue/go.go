@@ -363,6 +363,20 @@ func convert(ctx *context, src source, x interface{}) evaluated {
 			// be of fixed size and all elements will already have a defined
 			// value.
 			return list
+
+		// Named types with a basic underlying type, such as string enums.
+		case reflect.Bool:
+			return &boolLit{src.base(), value.Bool()}
+		case reflect.String:
+			return &stringLit{src.base(), value.String(), nil}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return toInt(ctx, src, value.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return toUint(ctx, src, value.Uint())
+		case reflect.Float32, reflect.Float64:
+			r := newNum(src, floatKind)
+			r.v.SetString(fmt.Sprintf("%g", value.Float()))
+			return r
 		}
 	}
 	return ctx.mkErr(src, "builtin returned unsupported type %T", x)
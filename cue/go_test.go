@@ -29,6 +29,8 @@ func TestConvert(t *testing.T) {
 	d34 := mkBigInt(34)
 	n34 := mkBigInt(-34)
 	f34 := big.NewFloat(34.0000)
+	type myString string
+	type myInt int8
 	testCases := []struct {
 		goVal interface{}
 		want  string
@@ -113,6 +115,10 @@ func TestConvert(t *testing.T) {
 		&struct{ A int }{3}, "<0>{A: 3}",
 	}, {
 		(*struct{ A int })(nil), "(*null | _)",
+	}, {
+		myString("a"), `"a"`,
+	}, {
+		myInt(-3), "-3",
 	}, {
 		reflect.ValueOf(3), "3",
 	}, {
@@ -20,6 +20,7 @@ import (
 	"io/ioutil"
 	"math"
 	"math/big"
+	"net"
 	"reflect"
 	"strconv"
 	"strings"
@@ -27,6 +28,7 @@ import (
 
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/errors"
+	"github.com/cockroachdb/apd/v2"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -984,6 +986,29 @@ func TestDecode(t *testing.T) {
 		ints = append([]int{}, ints...)
 		return &ints
 	}
+	type Inner struct {
+		B int `json:"b"`
+	}
+	type Outer struct {
+		A int `json:"a"`
+	}
+	type embed struct {
+		Inner
+		*Outer
+		C string `json:"c"`
+	}
+	type embedUnexported struct {
+		*fields
+	}
+	type tagged struct {
+		A int `json:"a" cue:"<10"`
+		B int `json:"b" cue:"a+1"`
+	}
+	type nested struct {
+		A struct {
+			B []int `json:"b"`
+		} `json:"a"`
+	}
 	testCases := []struct {
 		value string
 		dst   interface{}
@@ -1033,6 +1058,70 @@ func TestDecode(t *testing.T) {
 	}, {
 		value: `[int]`,
 		err:   "cannot convert incomplete value",
+	}, {
+		value: `'foo'`,
+		dst:   new([]byte),
+		want:  &[]byte{'f', 'o', 'o'},
+	}, {
+		value: `"Zm9v"`,
+		dst:   new([]byte),
+		want:  &[]byte{'f', 'o', 'o'},
+	}, {
+		value: `"foo"`,
+		dst:   new([]byte),
+		err:   "cannot decode string into Go value of type []uint8",
+	}, {
+		value: `"127.0.0.1"`,
+		dst:   new(net.IP),
+		want:  func() *net.IP { ip := net.IPv4(127, 0, 0, 1); return &ip }(),
+	}, {
+		value: `{a: 1, b: 2.5, c: "x", d: [true, null]}`,
+		dst:   new(interface{}),
+		want: func() *interface{} {
+			var x interface{} = map[string]interface{}{
+				"a": int64(1),
+				"b": 2.5,
+				"c": "x",
+				"d": []interface{}{true, nil},
+			}
+			return &x
+		}(),
+	}, {
+		value: `{"1": "a", "2": "b"}`,
+		dst:   new(map[int]string),
+		want:  &map[int]string{1: "a", 2: "b"},
+	}, {
+		value: `{a: 1, b: 2, c: "c"}`,
+		dst:   &embed{},
+		want:  &embed{Inner{B: 2}, &Outer{A: 1}, "c"},
+	}, {
+		value: `{a: 1}`,
+		dst:   &embedUnexported{},
+		err:   "path a: cannot set embedded pointer to unexported struct type",
+	}, {
+		value: `{a: 3}`,
+		dst:   &tagged{},
+		want:  &tagged{A: 3},
+	}, {
+		value: `{a: 3, b: 4}`,
+		dst:   &tagged{},
+		want:  &tagged{A: 3, B: 4},
+	}, {
+		value: `{a: 12}`,
+		dst:   &tagged{},
+		err:   "path a: invalid value 12 (out of bound <10)",
+	}, {
+		value: `{a: 3, b: 5}`,
+		dst:   &tagged{},
+		err:   "path b: conflicting values 5 and 4",
+	}, {
+		value: `{a: {b: [1, "2"]}}`,
+		dst:   &nested{},
+		err:   "path a.b.1: cannot unmarshal string into Go value of type int",
+	}, {
+		value: `300`,
+		dst:   new(int8),
+		err:   "value 300 overflows Go value of type int8",
 	}}
 	for _, tc := range testCases {
 		t.Run(tc.value, func(t *testing.T) {
@@ -1047,6 +1136,56 @@ func TestDecode(t *testing.T) {
 	}
 }
 
+func TestDecodeNumbers(t *testing.T) {
+	const dec = "123456789012345678901234567890.123456789012345678901234567890"
+	v := getInstance(t, `{
+		i: 123456789012345678901234567890
+		d: `+dec+`
+		f: 1.5
+		e: 123456789012345678901234567890
+	}`).Value()
+
+	var x struct {
+		I *big.Int
+		D apd.Decimal
+		F *big.Float
+		E interface{}
+	}
+	if err := v.Decode(&x); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := x.I.String(), "123456789012345678901234567890"; got != want {
+		t.Errorf("big.Int: got %v; want %v", got, want)
+	}
+	if got := x.D.String(); got != dec {
+		t.Errorf("apd.Decimal: got %v; want %v", got, dec)
+	}
+	if got, want := x.F.String(), "1.5"; got != want {
+		t.Errorf("big.Float: got %v; want %v", got, want)
+	}
+	if i, ok := x.E.(*big.Int); !ok || i.Cmp(x.I) != 0 {
+		t.Errorf("interface: got %#v; want %v", x.E, x.I)
+	}
+}
+
+func TestDecodeValue(t *testing.T) {
+	v := getInstance(t, `{a: 1, b: int}`).Value()
+
+	var x struct {
+		A int
+		B Value
+	}
+	if err := v.Decode(&x); err != nil {
+		t.Fatal(err)
+	}
+	if x.A != 1 {
+		t.Errorf("got %v; want 1", x.A)
+	}
+	if got, want := fmt.Sprint(x.B), "int"; got != want {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
 func TestValidate(t *testing.T) {
 	testCases := []struct {
 		desc string
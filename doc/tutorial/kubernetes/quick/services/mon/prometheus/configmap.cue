@@ -79,14 +79,16 @@ configMap prometheus: {
 				// Prometheus. The discovery auth config is automatic if Prometheus runs inside
 				// the cluster. Otherwise, more config options have to be provided within the
 				// <kubernetes_sd_config>.
-				tls_config ca_file: "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
-				// If your node certificates are self-signed or use a different CA to the
-				// master CA, then disable certificate verification below. Note that
-				// certificate verification is an integral part of a secure infrastructure
-				// so this should only be disabled in a controlled environment. You can
-				// disable certificate verification by uncommenting the line below.
-				//
-				// insecure_skip_verify: true
+				tls_config: {
+					ca_file: "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+					// If your node certificates are self-signed or use a different CA to the
+					// master CA, then disable certificate verification below. Note that
+					// certificate verification is an integral part of a secure infrastructure
+					// so this should only be disabled in a controlled environment. You can
+					// disable certificate verification by uncommenting the line below.
+					//
+					// insecure_skip_verify: true
+				}
 				bearer_token_file: "/var/run/secrets/kubernetes.io/serviceaccount/token"
 
 				// Keep only the default/kubernetes service endpoints for the https port. This
@@ -0,0 +1,315 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package csv converts CSV and TSV files to CUE. Each row of a file becomes
+// a struct with a field for each column, named after the header row, or a
+// list if the file has no header. Position information is retained.
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/source"
+)
+
+// Config defines options for converting CSV files.
+type Config struct {
+	// Comma is the field delimiter. It defaults to ','.
+	Comma rune
+
+	// NoHeader indicates that the first row of a file holds values rather
+	// than column names. Each row is then converted to a list.
+	NoHeader bool
+
+	// Schema determines the type of the columns. A column is only converted
+	// to a number or boolean if the field of the schema with the name of
+	// the column allows this. Columns for which the schema has no field
+	// are inferred from their values. The schema is not used if NoHeader
+	// is set.
+	Schema cue.Value
+}
+
+// anyKind allows a column to be converted to any kind of value.
+const anyKind = cue.BoolKind | cue.NumberKind | cue.StringKind
+
+// A Decoder converts the rows of a CSV file to CUE expressions.
+//
+// The type of a column is inferred from all of its values: a column is
+// converted to booleans or numbers only if each of its values is a boolean
+// or a number as defined by JSON. A column of numbers holds floats if any of
+// its values is a float.
+type Decoder struct {
+	cfg    Config
+	file   *token.File
+	lines  []int // offsets of the start of each line
+	header []string
+	rows   []row
+	kinds  []cue.Kind // inferred kind of each column
+	err    error      // error after the last row
+}
+
+type row struct {
+	fields []string
+	pos    []token.Pos
+}
+
+// NewDecoder creates a decoder for the CSV file with the given filename and
+// contents. The src argument may be nil, in which case the file is read from
+// disk. A nil Config uses the defaults.
+func NewDecoder(filename string, src interface{}, c *Config) (*Decoder, error) {
+	b, err := source.Read(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	d := &Decoder{file: token.NewFile(filename, 0, len(b))}
+	if c != nil {
+		d.cfg = *c
+	}
+	d.file.SetLinesForContent(b)
+	d.lines = append(d.lines, 0)
+	for i, c := range b {
+		if c == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+
+	r := csv.NewReader(bytes.NewReader(b))
+	if d.cfg.Comma != 0 {
+		r.Comma = d.cfg.Comma
+	}
+	if !d.cfg.NoHeader {
+		header, err := r.Read()
+		if err == io.EOF {
+			return d, nil
+		}
+		if err != nil {
+			return nil, d.errf(err)
+		}
+		d.header = header
+	}
+	for {
+		record, err := r.Read()
+		if err != nil {
+			if err != io.EOF {
+				d.err = d.errf(err)
+			}
+			break
+		}
+		row := row{fields: record}
+		for i := range record {
+			row.pos = append(row.pos, d.pos(r.FieldPos(i)))
+		}
+		d.rows = append(d.rows, row)
+	}
+	d.inferKinds()
+	return d, nil
+}
+
+// inferKinds computes the kind of each column from the schema and the values
+// of the column.
+func (d *Decoder) inferKinds() {
+	for _, row := range d.rows {
+		for i, s := range row.fields {
+			if i == len(d.kinds) {
+				d.kinds = append(d.kinds, d.schemaKind(i))
+			}
+			d.kinds[i] &= valueKind(s)
+		}
+	}
+}
+
+// schemaKind reports the kinds allowed for the given column by the schema.
+func (d *Decoder) schemaKind(column int) cue.Kind {
+	if d.cfg.NoHeader || !d.cfg.Schema.Exists() || column >= len(d.header) {
+		return anyKind
+	}
+	if v := d.cfg.Schema.Lookup(d.header[column]); v.Exists() {
+		return v.IncompleteKind()
+	}
+	return anyKind
+}
+
+// valueKind reports the kinds to which s can be converted. An integer may
+// also be converted to a float.
+func valueKind(s string) cue.Kind {
+	switch {
+	case s == "true" || s == "false":
+		return cue.BoolKind | cue.StringKind
+	case !isNumber(s):
+		return cue.StringKind
+	case strings.ContainsAny(s, ".eE"):
+		return cue.FloatKind | cue.StringKind
+	}
+	return cue.NumberKind | cue.StringKind
+}
+
+// isNumber reports whether s is a number as defined by JSON.
+func isNumber(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	if s == "" || s[0] == '0' && len(s) > 1 && isDigit(s[1]) {
+		return false
+	}
+	i := digits(s, 0)
+	if i == 0 {
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		j := digits(s, i+1)
+		if j == i+1 {
+			return false
+		}
+		i = j
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		j := digits(s, i)
+		if j == i {
+			return false
+		}
+		i = j
+	}
+	if i != len(s) {
+		return false
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil || err.(*strconv.NumError).Err == strconv.ErrRange
+}
+
+// digits returns the offset of the first non-digit in s at or after i.
+func digits(s string, i int) int {
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return i
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+// Decode returns the next row of the file as a CUE expression. It returns
+// io.EOF if there are no more rows.
+func (d *Decoder) Decode() (ast.Expr, error) {
+	if len(d.rows) == 0 {
+		if d.err != nil {
+			return nil, d.err
+		}
+		return nil, io.EOF
+	}
+	row := d.rows[0]
+	d.rows = d.rows[1:]
+
+	if d.cfg.NoHeader {
+		list := &ast.ListLit{}
+		for i, s := range row.fields {
+			list.Elts = append(list.Elts, d.value(row.pos[i], s, d.kinds[i]))
+		}
+		list.Lbrack = list.Elts[0].Pos()
+		return list, nil
+	}
+
+	obj := &ast.StructLit{}
+	for i, s := range row.fields {
+		obj.Elts = append(obj.Elts, &ast.Field{
+			Label: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(d.header[i])},
+			Value: d.value(row.pos[i], s, d.kinds[i]),
+		})
+	}
+	obj.Lbrace = obj.Elts[0].(*ast.Field).Value.Pos()
+	return obj, nil
+}
+
+// value converts a field to a literal of the inferred kind of its column.
+func (d *Decoder) value(pos token.Pos, s string, kind cue.Kind) ast.Expr {
+	switch {
+	case kind&cue.BoolKind != 0:
+		return &ast.BasicLit{ValuePos: pos, Kind: token.Lookup(s), Value: s}
+
+	case kind&cue.NumberKind != 0:
+		lit := &ast.BasicLit{ValuePos: pos, Kind: token.INT, Value: s}
+		if kind&cue.IntKind == 0 {
+			lit.Kind = token.FLOAT
+			if !strings.ContainsAny(s, ".eE") {
+				lit.Value += ".0"
+			}
+		}
+		if s[0] != '-' {
+			return lit
+		}
+		lit.Value = lit.Value[1:]
+		return &ast.UnaryExpr{OpPos: pos, Op: token.SUB, X: lit}
+	}
+	return &ast.BasicLit{ValuePos: pos, Kind: token.STRING, Value: strconv.Quote(s)}
+}
+
+func (d *Decoder) pos(line, column int) token.Pos {
+	if line < 1 || line > len(d.lines) {
+		return token.NoPos
+	}
+	offset := d.lines[line-1] + column - 1
+	if offset > d.file.Size() {
+		return token.NoPos
+	}
+	return d.file.Pos(offset, 0)
+}
+
+func (d *Decoder) errf(err error) error {
+	if e, ok := err.(*csv.ParseError); ok {
+		return errors.Newf(d.pos(e.Line, e.Column), "csv: %v", e.Err)
+	}
+	return err
+}
+
+// Extract parses the CSV file to a CUE file with a list of the rows as its
+// emit value.
+func Extract(filename string, src interface{}, c *Config) (*ast.File, error) {
+	d, err := NewDecoder(filename, src, c)
+	if err != nil {
+		return nil, err
+	}
+	list := &ast.ListLit{}
+	for {
+		expr, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		list.Elts = append(list.Elts, expr)
+	}
+	return &ast.File{
+		Filename: filename,
+		Decls:    []ast.Decl{&ast.EmitDecl{Expr: list}},
+	}, nil
+}
+
+// Decode converts a CSV file to a CUE instance with a list of the rows as
+// its emit value.
+func Decode(r *cue.Runtime, filename string, src interface{}, c *Config) (*cue.Instance, error) {
+	file, err := Extract(filename, src, c)
+	if err != nil {
+		return nil, err
+	}
+	return r.CompileFile(file)
+}
@@ -0,0 +1,147 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csv
+
+import (
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/format"
+)
+
+func TestCSV(t *testing.T) {
+	r := &cue.Runtime{}
+	schema, err := r.Compile("schema", `zip: string, count: int`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name string
+		csv  string
+		cfg  *Config
+		want string
+	}{{
+		name: "infer",
+		csv: `name,count,price,active,zip
+apple,3,1.25,true,01234
+pear,-1,0.5,false,-
+`,
+		want: `[{
+	name:   "apple"
+	count:  3
+	price:  1.25
+	active: true
+	zip:    "01234"
+}, {
+	name:   "pear"
+	count:  -1
+	price:  0.5
+	active: false
+	zip:    "-"
+}]`,
+	}, {
+		name: "columns",
+		csv: `zip,amount,flag,hex,size
+02134,1,true,0x10,5K
+10001,2.5,yes,16,1_000
+`,
+		want: `[{
+	zip:    "02134"
+	amount: 1.0
+	flag:   "true"
+	hex:    "0x10"
+	size:   "5K"
+}, {
+	zip:    "10001"
+	amount: 2.5
+	flag:   "yes"
+	hex:    "16"
+	size:   "1_000"
+}]`,
+	}, {
+		name: "numbers",
+		csv: `a,b,c
+-0,1e3,-12345678901234567890
+0.5,-1.5E-3,0
+`,
+		want: `[{
+	a: -0.0
+	b: 1e3
+	c: -12345678901234567890
+}, {
+	a: 0.5
+	b: -1.5E-3
+	c: 0
+}]`,
+	}, {
+		name: "schema",
+		csv: `zip,count,code
+1234,3,7
+`,
+		cfg: &Config{Schema: schema.Value()},
+		want: `[{
+	zip:   "1234"
+	count: 3
+	code:  7
+}]`,
+	}, {
+		name: "tsv",
+		csv:  "first name\tage\nJohn\t42\n",
+		cfg:  &Config{Comma: '\t'},
+		want: `[{
+	"first name": "John"
+	age:          42
+}]`,
+	}, {
+		name: "no header",
+		csv: `a,1
+b,2
+`,
+		cfg:  &Config{NoHeader: true},
+		want: `[["a", 1], ["b", 2]]`,
+	}, {
+		name: "empty",
+		want: `[]`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := Extract(tc.name, tc.csv, tc.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, _ := format.Node(f, format.Simplify())
+			if got := strings.TrimSpace(string(b)); got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+
+			if _, err := Decode(r, tc.name, tc.csv, tc.cfg); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestCSVError(t *testing.T) {
+	_, err := Extract("bad.csv", "a,b\n1,2\n3\n", nil)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	const want = "csv: wrong number of fields"
+	if got := err.Error(); !strings.Contains(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package toml converts TOML encodings to and from CUE. When converting to
+// CUE, comments and position information are retained.
+//
+// Dates and times, which have no counterpart in CUE, are converted to
+// strings. Infinite and NaN floats cannot be converted.
+package toml
+
+import (
+	"encoding/json"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/internal/encoding/toml"
+	"cuelang.org/go/internal/source"
+)
+
+// Extract parses the TOML to a CUE file.
+func Extract(filename string, src interface{}) (*ast.File, error) {
+	b, err := source.Read(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	return toml.Parse(filename, b)
+}
+
+// Decode converts a TOML file to a CUE value.
+func Decode(r *cue.Runtime, filename string, src interface{}) (*cue.Instance, error) {
+	file, err := Extract(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	return r.CompileFile(file)
+}
+
+// Encode returns the TOML encoding of v, which must be a struct.
+func Encode(v cue.Value) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		if x, ok := err.(*json.MarshalerError); ok {
+			err = x.Err
+		}
+		return nil, err
+	}
+	expr, err := parser.ParseExpr("", b)
+	if err != nil {
+		return nil, err
+	}
+	return toml.Marshal(expr)
+}
@@ -0,0 +1,349 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toml
+
+import (
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/format"
+)
+
+func TestExtract(t *testing.T) {
+	testCases := []struct {
+		name string
+		toml string
+		want string
+	}{{
+		name: "values",
+		toml: `
+str = "a\tb \u00e9"
+lit = 'C:\path'
+int = +1_000
+neg = -17
+hex = 0xff
+oct = 0o755
+bin = 0b11
+flt = 6.626e-34
+yes = true
+date = 1979-05-27 07:32:00Z
+`,
+		want: `str:  "a\tb é"
+lit:  "C:\\path"
+int:  1000
+neg:  -17
+hex:  255
+oct:  493
+bin:  3
+flt:  6.626e-34
+yes:  true
+date: "1979-05-27 07:32:00Z"`,
+	}, {
+		name: "multi-line strings",
+		toml: `
+a = """
+one \
+  two"""
+b = '''
+C:\path
+'''
+c = """"quoted"""""
+`,
+		want: `a: "one two"
+b: "C:\\path\n"
+c: "\"quoted\"\""`,
+	}, {
+		name: "keys",
+		toml: `
+bare-key = 1
+"quoted key" = 2
+'literal' = 3
+site."google.com" = true
+`,
+		want: `"bare-key":   1
+"quoted key": 2
+literal:      3
+site "google.com": true`,
+	}, {
+		name: "hidden and keywords",
+		toml: `
+_private = 1
+true = 2
+if = 3
+[server]
+_port = 80
+`,
+		want: `"_private": 1
+"true":     2
+"if":       3
+server: {
+	"_port": 80
+}`,
+	}, {
+		name: "arrays and inline tables",
+		toml: `
+ports = [ 8000, 8001 ]
+nested = [
+  [1, 2],
+  ["a"], # trailing comma
+]
+point = { x = 1, y.z = 2 }
+`,
+		want: `ports: [8000, 8001]
+nested: [
+	[1, 2],
+	["a"], // trailing comma
+]
+point: {x: 1, y z: 2}`,
+	}, {
+		name: "tables",
+		toml: `# The title.
+title = "example" # a title
+
+[owner]
+name = "Tom"
+
+# Servers.
+[servers.alpha]
+ip = "10.0.0.1"
+
+[fruit]
+apple.color = "red"
+apple.taste = "sweet"
+
+[[products]]
+name = "Hammer"
+
+[[products]]
+name = "Nail"
+`,
+		want: `// The title.
+title: "example" // a title
+
+owner: {
+	name: "Tom"
+}
+
+// Servers.
+servers alpha: {
+	ip: "10.0.0.1"
+}
+
+fruit: {
+	apple: {
+		color: "red"
+		taste: "sweet"
+	}
+}
+
+products: [{
+	name: "Hammer"
+}, {
+	name: "Nail"
+}]`,
+	}, {
+		name: "implicit tables",
+		toml: `
+[a.b]
+x = 1
+[a]
+y = 2
+[[a.c]]
+[a.c.d]
+z = 3
+`,
+		want: `a: {
+	b: {
+		x: 1
+	}
+	y: 2
+	c: [{
+		d: {
+			z: 3
+		}
+	}]
+}`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := Extract(tc.name, tc.toml)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := format.Node(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(string(b)); got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExtractErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		toml string
+		err  string
+	}{{
+		name: "duplicate key",
+		toml: "a = 1\na = 2",
+		err:  `toml: duplicate key "a"`,
+	}, {
+		name: "duplicate table",
+		toml: "[a]\n[a]",
+		err:  `toml: table "a" already defined`,
+	}, {
+		name: "extend inline table",
+		toml: "a = {}\n[a.b]",
+		err:  `toml: key "a.b" already defined as a value`,
+	}, {
+		name: "redefine dotted table",
+		toml: "[a]\nb.c = 1\n[a.b]",
+		err:  `toml: table "a.b" already defined`,
+	}, {
+		name: "array of tables",
+		toml: "a = [1]\n[[a]]",
+		err:  `toml: cannot define array of tables "a": key already defined`,
+	}, {
+		name: "missing newline",
+		toml: "a = 1 b = 2",
+		err:  `toml: expected newline, found 'b'`,
+	}, {
+		name: "infinity",
+		toml: "a = -inf",
+		err:  `toml: -inf cannot be represented in CUE`,
+	}, {
+		name: "leading zero",
+		toml: "a = 01",
+		err:  `toml: invalid value "01"`,
+	}, {
+		name: "unterminated string",
+		toml: `a = "abc`,
+		err:  `toml: unterminated string`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Extract(tc.name, tc.toml)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("got %v; want %v", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	testCases := []struct {
+		name string
+		cue  string
+		want string
+		err  string
+	}{{
+		name: "values",
+		cue: `
+a: "x\ty \"z\""
+b: 1
+c: -2.5
+d: [1, "two", [true]]
+e: {x: 1, "y z": [{}]}
+"f.g": 3
+`,
+		want: `a = "x\ty \"z\""
+b = 1
+c = -2.5
+d = [1, "two", [true]]
+"f.g" = 3
+
+[e]
+x = 1
+
+[[e."y z"]]
+`,
+	}, {
+		name: "tables",
+		cue: `
+servers alpha ip: "10.0.0.1"
+servers beta ip: "10.0.0.2"
+title: "example"
+products: [{name: "Hammer"}, {name: "Nail", tags: {a: 1}}]
+`,
+		want: `title = "example"
+
+[servers.alpha]
+ip = "10.0.0.1"
+
+[servers.beta]
+ip = "10.0.0.2"
+
+[[products]]
+name = "Hammer"
+
+[[products]]
+name = "Nail"
+
+[products.tags]
+a = 1
+`,
+	}, {
+		name: "empty table",
+		cue:  `a: {}`,
+		want: "[a]\n",
+	}, {
+		name: "not a struct",
+		cue:  `[1, 2]`,
+		err:  "toml: top-level value must be a struct",
+	}, {
+		name: "null",
+		cue:  `a: null`,
+		err:  "toml: cannot encode null",
+	}}
+	var r cue.Runtime
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inst, err := r.Compile(tc.name, tc.cue)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := Encode(inst.Value())
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("got %v; want %v", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+
+			// Decoding the result should give the original value.
+			f, err := Extract(tc.name, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := r.CompileFile(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Value().Equals(inst.Value()) {
+				t.Errorf("round trip of %s gave a different value", tc.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,879 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package toml converts TOML to CUE syntax trees and CUE data to TOML.
+//
+// The package does not depend on package cue so that it can be used by the
+// builtins.
+package toml
+
+import (
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+// A table is a TOML table under construction.
+type table struct {
+	lit    *ast.StructLit
+	fields map[string]*entry
+	header bool // defined by a [table] header
+	dotted bool // defined by a dotted key
+	inline bool // defined by an inline table and closed for extension
+}
+
+func newTable() *table {
+	return &table{lit: &ast.StructLit{}, fields: map[string]*entry{}}
+}
+
+// An entry is a key defined within a table.
+type entry struct {
+	field *ast.Field
+	table *table // the value if it is a table
+
+	// array and last are set for arrays of tables.
+	array *ast.ListLit
+	last  *table
+}
+
+// add adds a field for the given key with the given value to t.
+func (t *table) add(k key, value ast.Expr) *entry {
+	e := &entry{field: &ast.Field{Label: k.label(), Value: value}}
+	t.fields[k.name] = e
+	t.lit.Elts = append(t.lit.Elts, e.field)
+	return e
+}
+
+// A key is a part of a possibly dotted key.
+type key struct {
+	name string
+	pos  token.Pos
+}
+
+func (k key) label() ast.Label {
+	if isIdent(k.name) {
+		return &ast.Ident{NamePos: k.pos, Name: k.name}
+	}
+	return &ast.BasicLit{ValuePos: k.pos, Kind: token.STRING, Value: strconv.Quote(k.name)}
+}
+
+// isIdent reports whether s can be used as an unquoted label. Names that
+// start with an underscore, which would define hidden fields in CUE, and
+// keywords are quoted.
+func isIdent(s string) bool {
+	if s == "" || s[0] == '_' || token.Lookup(s) != token.IDENT {
+		return false
+	}
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+func joinKeys(keys []key) string {
+	a := make([]string, len(keys))
+	for i, k := range keys {
+		a[i] = k.name
+	}
+	return strings.Join(a, ".")
+}
+
+type parser struct {
+	file *token.File
+	src  []byte
+	off  int
+	prev token.Pos
+
+	// noSpace indicates that the next node follows an opening bracket and
+	// is not separated from it by a space if it is on the same line.
+	noSpace bool
+
+	root *table
+	cur  *table
+
+	comments []*ast.Comment // comments not yet attached to a node
+}
+
+// Parse converts the given TOML source to a CUE file, retaining comments
+// and position information.
+func Parse(filename string, src []byte) (*ast.File, error) {
+	p := &parser{
+		file: token.NewFile(filename, 0, len(src)),
+		src:  src,
+		root: newTable(),
+	}
+	p.file.SetLinesForContent(src)
+	p.cur = p.root
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	f := &ast.File{Filename: filename, Decls: p.root.lit.Elts}
+	if len(p.comments) > 0 {
+		f.AddComment(&ast.CommentGroup{Position: 100, List: p.comments})
+	}
+	return f, nil
+}
+
+func (p *parser) parse() error {
+	for {
+		p.skipSpace()
+		if p.off >= len(p.src) {
+			return nil
+		}
+		var err error
+		switch p.src[p.off] {
+		case '\r', '\n':
+			err = p.expectEOL()
+		case '#':
+			p.comments = append(p.comments, p.comment())
+			err = p.expectEOL()
+		case '[':
+			err = p.header()
+		default:
+			err = p.keyValue(p.cur)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *parser) errf(off int, format string, args ...interface{}) error {
+	if off > len(p.src) {
+		off = len(p.src)
+	}
+	return errors.Newf(p.file.Pos(off, 0), "toml: "+format, args...)
+}
+
+// pos returns the position for the given offset. The relative position is
+// derived from the position of the previous node, which is sufficient as
+// nodes are created in the order in which they appear in the source.
+func (p *parser) pos(off int) token.Pos {
+	pos := p.file.Pos(off, token.NoRelPos)
+	if p.prev.IsValid() {
+		c := pos.Position()
+		prev := p.prev.Position()
+		switch {
+		case c.Line-prev.Line >= 2:
+			pos = pos.WithRel(token.NewSection)
+		case c.Line-prev.Line == 1:
+			pos = pos.WithRel(token.Newline)
+		case c.Column-prev.Column > 0 && !p.noSpace:
+			pos = pos.WithRel(token.Blank)
+		default:
+			pos = pos.WithRel(token.NoSpace)
+		}
+	}
+	p.prev = pos
+	p.noSpace = false
+	return pos
+}
+
+func (p *parser) peek(s string) bool {
+	return strings.HasPrefix(string(p.src[p.off:]), s)
+}
+
+func (p *parser) skipSpace() {
+	for p.off < len(p.src) && (p.src[p.off] == ' ' || p.src[p.off] == '\t') {
+		p.off++
+	}
+}
+
+// skipLines skips whitespace, newlines, and comments, as allowed within
+// arrays.
+func (p *parser) skipLines() {
+	for {
+		p.skipSpace()
+		switch {
+		case p.peek("\n"):
+			p.off++
+		case p.peek("\r\n"):
+			p.off += 2
+		case p.peek("#"):
+			p.comments = append(p.comments, p.comment())
+		default:
+			return
+		}
+	}
+}
+
+// comment consumes a comment up to the end of the line.
+func (p *parser) comment() *ast.Comment {
+	start := p.off
+	for p.off < len(p.src) && p.src[p.off] != '\n' && !p.peek("\r\n") {
+		p.off++
+	}
+	return &ast.Comment{
+		Slash: p.file.Pos(start, 0),
+		Text:  "//" + string(p.src[start+1:p.off]),
+	}
+}
+
+// lineComment consumes the remainder of a line, which may only hold a
+// comment, and returns this comment, if any.
+func (p *parser) lineComment() (*ast.Comment, error) {
+	p.skipSpace()
+	var c *ast.Comment
+	if p.peek("#") {
+		c = p.comment()
+	}
+	return c, p.expectEOL()
+}
+
+func (p *parser) expectEOL() error {
+	switch {
+	case p.off >= len(p.src):
+	case p.peek("\n"):
+		p.off++
+	case p.peek("\r\n"):
+		p.off += 2
+	default:
+		return p.errf(p.off, "expected newline, found %q", p.src[p.off])
+	}
+	return nil
+}
+
+// attachDoc adds the pending comments to n as a doc comment. If n is a field
+// that starts a new section, the section starts before the comments instead.
+func (p *parser) attachDoc(n ast.Node) {
+	if len(p.comments) == 0 {
+		return
+	}
+	if f, ok := n.(*ast.Field); ok && f.Label.Pos().RelPos() == token.NewSection {
+		c := p.comments[0]
+		c.Slash = c.Slash.WithRel(token.NewSection)
+		switch x := f.Label.(type) {
+		case *ast.Ident:
+			x.NamePos = x.NamePos.WithRel(token.Newline)
+		case *ast.BasicLit:
+			x.ValuePos = x.ValuePos.WithRel(token.Newline)
+		}
+	}
+	n.AddComment(&ast.CommentGroup{Doc: true, List: p.comments})
+	p.comments = nil
+}
+
+// attachLine adds c, if not nil, as a comment following x.
+func attachLine(x ast.Expr, c *ast.Comment) {
+	if c != nil {
+		x.AddComment(&ast.CommentGroup{Line: true, Position: 10, List: []*ast.Comment{c}})
+	}
+}
+
+// header parses a [table] or [[array of tables]] header and makes its table
+// the current table.
+func (p *parser) header() error {
+	start := p.off
+	isArray := p.peek("[[")
+	if isArray {
+		p.off += 2
+	} else {
+		p.off++
+	}
+	p.skipSpace()
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if isArray && !p.peek("]]") || !p.peek("]") {
+		return p.errf(p.off, "expected closing bracket for table header")
+	}
+	if isArray {
+		p.off += 2
+	} else {
+		p.off++
+	}
+	c, err := p.lineComment()
+	if err != nil {
+		return err
+	}
+	if c != nil {
+		p.comments = append(p.comments, c)
+	}
+
+	for i := 1; i < len(keys); i++ {
+		keys[i].pos = keys[i].pos.WithRel(token.Newline)
+	}
+
+	// Doc comments are attached to the outermost field defined by the header.
+	var node ast.Node
+	t := p.root
+	for _, k := range keys[:len(keys)-1] {
+		switch e := t.fields[k.name]; {
+		case e == nil:
+			nt := newTable()
+			nt.lit.Rbrace = token.Newline.Pos()
+			e = t.add(k, nt.lit)
+			e.table = nt
+			if node == nil {
+				node = e.field
+			}
+			t = nt
+		case e.last != nil:
+			t = e.last
+		case e.table != nil && !e.table.inline:
+			t = e.table
+		default:
+			return p.errf(start, "key %q already defined as a value", joinKeys(keys))
+		}
+	}
+
+	k := keys[len(keys)-1]
+	e := t.fields[k.name]
+	switch {
+	case isArray && e == nil:
+		e = t.add(k, &ast.ListLit{})
+		e.array = e.field.Value.(*ast.ListLit)
+		if node == nil {
+			node = e.field
+		}
+		fallthrough
+	case isArray && e.array != nil:
+		e.last = newTable()
+		e.last.header = true
+		e.last.lit.Rbrace = token.Newline.Pos()
+		e.array.Elts = append(e.array.Elts, e.last.lit)
+		if node == nil {
+			node = e.last.lit
+		}
+		p.cur = e.last
+
+	case isArray:
+		return p.errf(start, "cannot define array of tables %q: key already defined", joinKeys(keys))
+
+	case e == nil:
+		nt := newTable()
+		e = t.add(k, nt.lit)
+		e.table = nt
+		if node == nil {
+			node = e.field
+		}
+		fallthrough
+
+	case e.table != nil && !e.table.header && !e.table.dotted && !e.table.inline:
+		// A table defined by a header is not collapsed with its fields.
+		e.table.header = true
+		e.table.lit.Lbrace = token.Blank.Pos()
+		e.table.lit.Rbrace = token.Newline.Pos()
+		if node == nil {
+			node = e.field
+		}
+		p.cur = e.table
+
+	default:
+		return p.errf(start, "table %q already defined", joinKeys(keys))
+	}
+	p.attachDoc(node)
+	return nil
+}
+
+// keyValue parses a key/value pair and adds it to t.
+func (p *parser) keyValue(t *table) error {
+	start := p.off
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if !p.peek("=") {
+		return p.errf(p.off, "expected '=' after key %q", joinKeys(keys))
+	}
+	p.off++
+	p.skipSpace()
+	// Comments within the value are added to the doc comments of the key.
+	doc := p.comments
+	p.comments = nil
+	value, err := p.value()
+	if err != nil {
+		return err
+	}
+	p.comments = append(doc, p.comments...)
+	f, err := p.insert(t, start, keys, value)
+	if err != nil {
+		return err
+	}
+	if t.inline {
+		return nil
+	}
+	c, err := p.lineComment()
+	if err != nil {
+		return err
+	}
+	attachLine(f.Value, c)
+	return nil
+}
+
+// insert adds a field for a dotted key with the given value to t.
+func (p *parser) insert(t *table, start int, keys []key, value ast.Expr) (*ast.Field, error) {
+	if !t.inline {
+		// Lay out the fields of tables defined by dotted keys on separate
+		// lines, as they may be defined on different lines.
+		for i := 1; i < len(keys); i++ {
+			keys[i].pos = keys[i].pos.WithRel(token.Newline)
+		}
+	}
+	for _, k := range keys[:len(keys)-1] {
+		switch e := t.fields[k.name]; {
+		case e == nil:
+			nt := newTable()
+			nt.dotted = true
+			nt.inline = t.inline
+			e = t.add(k, nt.lit)
+			e.table = nt
+			if !t.inline {
+				nt.lit.Rbrace = token.Newline.Pos()
+				p.attachDoc(e.field)
+			}
+			t = nt
+		case e.table != nil && e.table.dotted && e.table.inline == t.inline:
+			t = e.table
+		default:
+			return nil, p.errf(start, "cannot define key %q: %q already defined", joinKeys(keys), k.name)
+		}
+	}
+	k := keys[len(keys)-1]
+	if t.fields[k.name] != nil {
+		return nil, p.errf(start, "duplicate key %q", joinKeys(keys))
+	}
+	e := t.add(k, value)
+	if lit, ok := value.(*ast.StructLit); ok {
+		e.table = &table{lit: lit, inline: true}
+	}
+	if !t.inline {
+		p.attachDoc(e.field)
+	}
+	return e.field, nil
+}
+
+// key parses a possibly dotted key.
+func (p *parser) key() ([]key, error) {
+	var keys []key
+	for {
+		k, err := p.simpleKey()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+		p.skipSpace()
+		if !p.peek(".") {
+			return keys, nil
+		}
+		p.off++
+		p.skipSpace()
+	}
+}
+
+func (p *parser) simpleKey() (key, error) {
+	start := p.off
+	switch {
+	case p.peek(`"`):
+		s, err := p.basicString()
+		return key{name: s, pos: p.pos(start)}, err
+	case p.peek("'"):
+		s, err := p.literalString()
+		return key{name: s, pos: p.pos(start)}, err
+	}
+	for p.off < len(p.src) && isBare(p.src[p.off]) {
+		p.off++
+	}
+	if p.off == start {
+		if p.off >= len(p.src) {
+			return key{}, p.errf(p.off, "expected key, found end of file")
+		}
+		return key{}, p.errf(p.off, "expected key, found %q", p.src[p.off])
+	}
+	return key{name: string(p.src[start:p.off]), pos: p.pos(start)}, nil
+}
+
+func isBare(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' ||
+		'0' <= c && c <= '9' || c == '_' || c == '-'
+}
+
+// value parses a value and converts it to a CUE expression.
+func (p *parser) value() (ast.Expr, error) {
+	start := p.off
+	if start >= len(p.src) {
+		return nil, p.errf(start, "expected value, found end of file")
+	}
+	switch p.src[start] {
+	case '"':
+		var s string
+		var err error
+		if p.peek(`"""`) {
+			s, err = p.multiLineString(`"""`)
+		} else {
+			s, err = p.basicString()
+		}
+		return p.stringLit(start, s), err
+
+	case '\'':
+		var s string
+		var err error
+		if p.peek("'''") {
+			s, err = p.multiLineString("'''")
+		} else {
+			s, err = p.literalString()
+		}
+		return p.stringLit(start, s), err
+
+	case '[':
+		return p.array()
+
+	case '{':
+		return p.inlineTable()
+	}
+
+	for p.off < len(p.src) && isValueChar(p.src[p.off]) {
+		p.off++
+	}
+	// A date may be separated from a time by a space.
+	if localDate.Match(p.src[start:p.off]) && p.off+3 < len(p.src) &&
+		p.src[p.off] == ' ' && isDigit(p.src[p.off+1]) && isDigit(p.src[p.off+2]) && p.src[p.off+3] == ':' {
+		p.off++
+		for p.off < len(p.src) && isValueChar(p.src[p.off]) {
+			p.off++
+		}
+	}
+	s := string(p.src[start:p.off])
+	switch {
+	case s == "":
+		return nil, p.errf(start, "expected value, found %q", p.src[start])
+
+	case s == "true" || s == "false":
+		return &ast.BasicLit{ValuePos: p.pos(start), Kind: token.Lookup(s), Value: s}, nil
+
+	case decInt.MatchString(s):
+		return p.number(start, token.INT, s), nil
+
+	case prefixedInt.MatchString(s):
+		var i big.Int
+		if _, ok := i.SetString(s, 0); !ok {
+			return nil, p.errf(start, "invalid integer %q", s)
+		}
+		return p.number(start, token.INT, i.String()), nil
+
+	case float.MatchString(s):
+		return p.number(start, token.FLOAT, s), nil
+
+	case special.MatchString(s):
+		return nil, p.errf(start, "%s cannot be represented in CUE", s)
+
+	case isDateTime(s):
+		return p.stringLit(start, s), nil
+	}
+	return nil, p.errf(start, "invalid value %q", s)
+}
+
+var (
+	decInt      = regexp.MustCompile(`^[-+]?(0|[1-9](_?[0-9])*)$`)
+	prefixedInt = regexp.MustCompile(`^(0x[0-9A-Fa-f](_?[0-9A-Fa-f])*|0o[0-7](_?[0-7])*|0b[01](_?[01])*)$`)
+	float       = regexp.MustCompile(`^[-+]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*([eE][-+]?[0-9](_?[0-9])*)?|[eE][-+]?[0-9](_?[0-9])*)$`)
+	special     = regexp.MustCompile(`^[-+]?(inf|nan)$`)
+	localDate   = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+)
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+func isValueChar(c byte) bool {
+	return isBare(c) || c == '+' || c == '.' || c == ':'
+}
+
+// isDateTime reports whether s is a valid offset date-time, local date-time,
+// local date, or local time.
+func isDateTime(s string) bool {
+	layouts := []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02",
+		"15:04:05.999999999",
+	}
+	if len(s) > 10 && (s[10] == ' ' || s[10] == 't') {
+		s = s[:10] + "T" + s[11:]
+	}
+	s = strings.Replace(s, "z", "Z", 1)
+	for _, layout := range layouts {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// number returns the literal for a number of the given kind. Underscores and
+// plus signs, which are not allowed in CUE, are removed.
+func (p *parser) number(off int, kind token.Token, s string) ast.Expr {
+	pos := p.pos(off)
+	s = strings.Replace(s, "_", "", -1)
+	s = strings.TrimPrefix(s, "+")
+	if strings.HasPrefix(s, "-") {
+		return &ast.UnaryExpr{
+			OpPos: pos,
+			Op:    token.SUB,
+			X:     &ast.BasicLit{ValuePos: pos, Kind: kind, Value: s[1:]},
+		}
+	}
+	return &ast.BasicLit{ValuePos: pos, Kind: kind, Value: s}
+}
+
+func (p *parser) stringLit(off int, s string) ast.Expr {
+	pos := p.pos(off)
+	// Position subsequent nodes relative to the end of multi-line strings.
+	p.prev = p.file.Pos(p.off-1, 0)
+	return &ast.BasicLit{ValuePos: pos, Kind: token.STRING, Value: strconv.Quote(s)}
+}
+
+// basicString parses a single-line string with escapes.
+func (p *parser) basicString() (string, error) {
+	start := p.off
+	p.off++
+	var b strings.Builder
+	for {
+		if p.off >= len(p.src) || p.src[p.off] == '\n' || p.peek("\r\n") {
+			return "", p.errf(start, "unterminated string")
+		}
+		switch c := p.src[p.off]; c {
+		case '"':
+			p.off++
+			return b.String(), nil
+		case '\\':
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+		default:
+			if err := p.char(&b); err != nil {
+				return "", err
+			}
+		}
+	}
+}
+
+// literalString parses a single-line string without escapes.
+func (p *parser) literalString() (string, error) {
+	start := p.off
+	p.off++
+	var b strings.Builder
+	for {
+		if p.off >= len(p.src) || p.src[p.off] == '\n' || p.peek("\r\n") {
+			return "", p.errf(start, "unterminated string")
+		}
+		if p.src[p.off] == '\'' {
+			p.off++
+			return b.String(), nil
+		}
+		if err := p.char(&b); err != nil {
+			return "", err
+		}
+	}
+}
+
+// multiLineString parses a multi-line basic or literal string delimited by
+// the given quotes.
+func (p *parser) multiLineString(quotes string) (string, error) {
+	start := p.off
+	p.off += len(quotes)
+	// A newline immediately following the opening delimiter is trimmed.
+	if p.peek("\n") {
+		p.off++
+	} else if p.peek("\r\n") {
+		p.off += 2
+	}
+	var b strings.Builder
+	for {
+		switch {
+		case p.off >= len(p.src):
+			return "", p.errf(start, "unterminated string")
+
+		case p.peek(quotes):
+			// Up to two additional quotes may precede the closing delimiter.
+			n := 0
+			for p.off+n < len(p.src) && p.src[p.off+n] == quotes[0] {
+				n++
+			}
+			if n > len(quotes)+2 {
+				return "", p.errf(p.off, "too many quotes in string")
+			}
+			b.WriteString(quotes[:n-len(quotes)])
+			p.off += n
+			return b.String(), nil
+
+		case p.src[p.off] == '\n':
+			b.WriteByte('\n')
+			p.off++
+
+		case p.peek("\r\n"):
+			b.WriteByte('\n')
+			p.off += 2
+
+		case quotes[0] == '"' && p.src[p.off] == '\\':
+			// A backslash at the end of a line trims all whitespace up to
+			// the next non-whitespace character.
+			i := p.off + 1
+			for i < len(p.src) && (p.src[i] == ' ' || p.src[i] == '\t') {
+				i++
+			}
+			if i < len(p.src) && (p.src[i] == '\n' || p.src[i] == '\r') {
+				for i < len(p.src) && strings.IndexByte(" \t\r\n", p.src[i]) >= 0 {
+					i++
+				}
+				p.off = i
+				continue
+			}
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+
+		default:
+			if err := p.char(&b); err != nil {
+				return "", err
+			}
+		}
+	}
+}
+
+// char consumes a single character of a string.
+func (p *parser) char(b *strings.Builder) error {
+	r, size := utf8.DecodeRune(p.src[p.off:])
+	if r == utf8.RuneError && size <= 1 {
+		return p.errf(p.off, "invalid UTF-8 encoding")
+	}
+	if r < 0x20 && r != '\t' || r == 0x7f {
+		return p.errf(p.off, "invalid control character %U in string", r)
+	}
+	b.WriteRune(r)
+	p.off += size
+	return nil
+}
+
+// escape consumes an escape sequence of a basic string.
+func (p *parser) escape(b *strings.Builder) error {
+	start := p.off
+	p.off++
+	if p.off >= len(p.src) {
+		return p.errf(start, "unterminated string")
+	}
+	c := p.src[p.off]
+	p.off++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case '"':
+		b.WriteByte('"')
+	case '\\':
+		b.WriteByte('\\')
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.off+n > len(p.src) {
+			return p.errf(start, "invalid escape sequence")
+		}
+		r, err := strconv.ParseUint(string(p.src[p.off:p.off+n]), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return p.errf(start, "invalid unicode escape %q", p.src[start:p.off+n])
+		}
+		b.WriteRune(rune(r))
+		p.off += n
+	default:
+		return p.errf(start, "invalid escape sequence %q", p.src[start:p.off])
+	}
+	return nil
+}
+
+// array parses an array, which may span multiple lines.
+func (p *parser) array() (ast.Expr, error) {
+	start := p.off
+	list := &ast.ListLit{Lbrack: p.pos(start)}
+	p.off++
+	for {
+		p.skipLines()
+		if p.peek("]") {
+			break
+		}
+		p.noSpace = len(list.Elts) == 0
+		x, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		p.attachDoc(x)
+		list.Elts = append(list.Elts, x)
+		p.skipLines()
+		if p.peek(",") {
+			p.off++
+			p.skipSpace()
+			if p.peek("#") {
+				attachLine(x, p.comment())
+			}
+			continue
+		}
+		if !p.peek("]") {
+			return nil, p.errf(p.off, "expected ',' or ']' in array")
+		}
+		break
+	}
+	list.Rbrack = p.pos(p.off)
+	p.off++
+	return list, nil
+}
+
+// inlineTable parses an inline table, which must be defined on a single
+// line.
+func (p *parser) inlineTable() (ast.Expr, error) {
+	start := p.off
+	t := newTable()
+	t.inline = true
+	t.lit.Lbrace = p.pos(start)
+	p.off++
+	p.skipSpace()
+	p.noSpace = true
+	if !p.peek("}") {
+		for {
+			if err := p.keyValue(t); err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			if p.peek("}") {
+				break
+			}
+			if !p.peek(",") {
+				return nil, p.errf(p.off, "expected ',' or '}' in inline table")
+			}
+			p.off++
+			p.skipSpace()
+		}
+	}
+	t.lit.Rbrace = p.pos(p.off)
+	p.off++
+	return t.lit, nil
+}
@@ -0,0 +1,275 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toml
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/literal"
+	"cuelang.org/go/cue/token"
+)
+
+// Marshal returns the TOML encoding of x, which must be a struct literal
+// holding only data, such as the result of parsing JSON.
+//
+// Fields with simple values are written before tables, which are written in
+// order of appearance. TOML has no null value, so null is not allowed.
+func Marshal(x ast.Expr) ([]byte, error) {
+	s, ok := x.(*ast.StructLit)
+	if !ok {
+		return nil, errors.Newf(x.Pos(), "toml: top-level value must be a struct")
+	}
+	e := &encoder{}
+	if err := e.table(nil, s); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+type encoder struct {
+	buf bytes.Buffer
+}
+
+// table writes the fields of s, which has the given path.
+func (e *encoder) table(path []string, s *ast.StructLit) error {
+	var tables []*ast.Field
+	for _, d := range s.Elts {
+		f, ok := d.(*ast.Field)
+		if !ok {
+			return errors.Newf(d.Pos(), "toml: unsupported declaration")
+		}
+		if isTable(f.Value) || isTableArray(f.Value) {
+			tables = append(tables, f)
+			continue
+		}
+		name, err := labelName(f.Label)
+		if err != nil {
+			return err
+		}
+		e.buf.WriteString(quoteKey(name))
+		e.buf.WriteString(" = ")
+		if err := e.value(f.Value); err != nil {
+			return err
+		}
+		e.buf.WriteByte('\n')
+	}
+
+	for _, f := range tables {
+		name, err := labelName(f.Label)
+		if err != nil {
+			return err
+		}
+		p := append(path[:len(path):len(path)], name)
+		switch x := f.Value.(type) {
+		case *ast.StructLit:
+			// A header may be omitted for tables that only hold tables.
+			if len(x.Elts) == 0 || !onlyTables(x) {
+				e.header("[", p, "]")
+			}
+			if err := e.table(p, x); err != nil {
+				return err
+			}
+
+		case *ast.ListLit:
+			for _, elt := range x.Elts {
+				e.header("[[", p, "]]")
+				if err := e.table(p, elt.(*ast.StructLit)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (e *encoder) header(open string, path []string, close string) {
+	if e.buf.Len() > 0 {
+		e.buf.WriteByte('\n')
+	}
+	e.buf.WriteString(open)
+	for i, name := range path {
+		if i > 0 {
+			e.buf.WriteByte('.')
+		}
+		e.buf.WriteString(quoteKey(name))
+	}
+	e.buf.WriteString(close)
+	e.buf.WriteByte('\n')
+}
+
+func isTable(x ast.Expr) bool {
+	_, ok := x.(*ast.StructLit)
+	return ok
+}
+
+// isTableArray reports whether x is a non-empty list of structs, which is
+// written as an array of tables.
+func isTableArray(x ast.Expr) bool {
+	list, ok := x.(*ast.ListLit)
+	if !ok || len(list.Elts) == 0 {
+		return false
+	}
+	for _, elt := range list.Elts {
+		if !isTable(elt) {
+			return false
+		}
+	}
+	return true
+}
+
+func onlyTables(s *ast.StructLit) bool {
+	for _, d := range s.Elts {
+		if f, ok := d.(*ast.Field); !ok || !isTable(f.Value) && !isTableArray(f.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// value writes x as an inline value.
+func (e *encoder) value(x ast.Expr) error {
+	switch x := x.(type) {
+	case *ast.BasicLit:
+		switch x.Kind {
+		case token.STRING:
+			s, err := literal.Unquote(x.Value)
+			if err != nil {
+				return errors.Newf(x.Pos(), "toml: %v", err)
+			}
+			e.buf.WriteString(quote(s))
+		case token.INT, token.FLOAT, token.TRUE, token.FALSE:
+			e.buf.WriteString(x.Value)
+		case token.NULL:
+			return errors.Newf(x.Pos(), "toml: cannot encode null")
+		default:
+			return errors.Newf(x.Pos(), "toml: unsupported literal %s", x.Value)
+		}
+
+	case *ast.UnaryExpr:
+		if x.Op != token.SUB {
+			return errors.Newf(x.Pos(), "toml: unsupported expression")
+		}
+		e.buf.WriteByte('-')
+		return e.value(x.X)
+
+	case *ast.ListLit:
+		e.buf.WriteByte('[')
+		for i, elt := range x.Elts {
+			if i > 0 {
+				e.buf.WriteString(", ")
+			}
+			if err := e.value(elt); err != nil {
+				return err
+			}
+		}
+		e.buf.WriteByte(']')
+
+	case *ast.StructLit:
+		if len(x.Elts) == 0 {
+			e.buf.WriteString("{}")
+			return nil
+		}
+		e.buf.WriteString("{ ")
+		for i, d := range x.Elts {
+			f, ok := d.(*ast.Field)
+			if !ok {
+				return errors.Newf(d.Pos(), "toml: unsupported declaration")
+			}
+			if i > 0 {
+				e.buf.WriteString(", ")
+			}
+			name, err := labelName(f.Label)
+			if err != nil {
+				return err
+			}
+			e.buf.WriteString(quoteKey(name))
+			e.buf.WriteString(" = ")
+			if err := e.value(f.Value); err != nil {
+				return err
+			}
+		}
+		e.buf.WriteString(" }")
+
+	default:
+		return errors.Newf(x.Pos(), "toml: unsupported expression")
+	}
+	return nil
+}
+
+func labelName(l ast.Label) (string, error) {
+	switch x := l.(type) {
+	case *ast.Ident:
+		return x.Name, nil
+	case *ast.BasicLit:
+		if x.Kind == token.STRING {
+			s, err := literal.Unquote(x.Value)
+			if err != nil {
+				return "", errors.Newf(x.Pos(), "toml: %v", err)
+			}
+			return s, nil
+		}
+	}
+	return "", errors.Newf(l.Pos(), "toml: unsupported label")
+}
+
+// quoteKey returns name as a bare key if possible, or as a quoted key
+// otherwise.
+func quoteKey(name string) string {
+	if name == "" {
+		return `""`
+	}
+	for i := 0; i < len(name); i++ {
+		if !isBare(name[i]) {
+			return quote(name)
+		}
+	}
+	return name
+}
+
+// quote returns s as a TOML basic string.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -33,6 +33,7 @@ type node struct {
 	tag      string
 	// For an alias node, alias holds the resolved alias.
 	alias    *node
+	anchor   string // name of the anchor of the node, if any
 	value    string
 	implicit bool
 	children []*node
@@ -161,6 +162,7 @@ func (p *parser) fail() {
 
 func (p *parser) anchor(n *node, anchor []byte) {
 	if anchor != nil {
+		n.anchor = string(anchor)
 		p.doc.anchors[string(anchor)] = n
 	}
 }
@@ -201,6 +203,9 @@ func (p *parser) document() *node {
 	p.doc = n
 	p.expect(yaml_DOCUMENT_START_EVENT)
 	n.children = append(n.children, p.parse())
+	// Record where the document ends to determine its foot comments.
+	p.peek()
+	n.endPos = p.event.start_mark
 	p.expect(yaml_DOCUMENT_END_EVENT)
 	return n
 }
@@ -266,6 +271,25 @@ type decoder struct {
 	prev         token.Pos
 	lastNode     ast.Node
 	forceNewline bool
+
+	// limit is the index before which the foot comments of the node being
+	// decoded must appear.
+	limit int
+
+	// refs indicates that aliases are converted to references to the
+	// anchored values, where possible, instead of to copies of them.
+	refs bool
+
+	path        []pathElem           // path of the node being decoded
+	anchorPaths map[*node][]pathElem // path of each decoded anchored node
+	scopes      []*node              // enclosing mappings, excluding the root
+}
+
+// A pathElem is a label of a field or, if label is nil, an index of a list
+// element.
+type pathElem struct {
+	label ast.Label
+	index int
 }
 
 var (
@@ -279,6 +303,7 @@ var (
 func newDecoder(p *parser) *decoder {
 	d := &decoder{p: p, mapType: defaultMapType}
 	d.aliases = make(map[*node]bool)
+	d.anchorPaths = make(map[*node][]pathElem)
 	return d
 }
 
@@ -300,6 +325,9 @@ func (d *decoder) terror(n *node, tag string) string {
 }
 
 func (d *decoder) unmarshal(n *node) (node ast.Expr) {
+	if _, ok := d.anchorPaths[n]; n.anchor != "" && !ok {
+		d.anchorPaths[n] = append([]pathElem(nil), d.path...)
+	}
 	switch n.kind {
 	case documentNode:
 		node = d.document(n)
@@ -360,6 +388,100 @@ func (d *decoder) attachLineComment(m yaml_mark_t, pos int8, expr ast.Node) {
 	}
 }
 
+// attachTrailingComment attaches a comment that follows the given end of a
+// node on the same line to expr.
+func (d *decoder) attachTrailingComment(m yaml_mark_t, expr ast.Node) {
+	if len(d.p.parser.comments) == 0 {
+		return
+	}
+	c := d.p.parser.comments[0]
+	if c.mark.line != m.line || c.mark.index < m.index || c.mark.index >= d.limit {
+		return
+	}
+	expr.AddComment(&ast.CommentGroup{
+		Line:     true,
+		Position: 10,
+		List: []*ast.Comment{{
+			Slash: d.pos(c.mark),
+			Text:  "//" + c.text[1:],
+		}},
+	})
+	d.p.parser.comments = d.p.parser.comments[1:]
+}
+
+// headComments returns the comments that follow the key of a field on the
+// same line if the value of the field continues on subsequent lines, as is
+// the case for block scalars and collections.
+func (d *decoder) headComments(key, value *node) []*ast.Comment {
+	if value.endPos.line == key.startPos.line {
+		return nil
+	}
+	var comments []*ast.Comment
+	for len(d.p.parser.comments) > 0 {
+		c := d.p.parser.comments[0]
+		if c.mark.line != key.startPos.line || c.mark.index < key.endPos.index {
+			break
+		}
+		comments = append(comments, &ast.Comment{
+			Slash: d.absPos(c.mark).WithRel(token.Newline),
+			Text:  "//" + c.text[1:],
+		})
+		d.p.parser.comments = d.p.parser.comments[1:]
+	}
+	return comments
+}
+
+// addDocComments appends the given comments to the doc comments of f.
+func (d *decoder) addDocComments(f *ast.Field, comments []*ast.Comment) {
+	if len(comments) == 0 {
+		return
+	}
+	// Move a section break before the label to the comments.
+	switch x := f.Label.(type) {
+	case *ast.Ident:
+		if x.NamePos.RelPos() == token.NewSection {
+			x.NamePos = x.NamePos.WithRel(token.Newline)
+			comments[0].Slash = comments[0].Slash.WithRel(token.NewSection)
+		}
+	case *ast.BasicLit:
+		if x.ValuePos.RelPos() == token.NewSection {
+			x.ValuePos = x.ValuePos.WithRel(token.Newline)
+			comments[0].Slash = comments[0].Slash.WithRel(token.NewSection)
+		}
+	}
+	for _, cg := range f.Comments() {
+		if cg.Doc {
+			cg.List = append(cg.List, comments...)
+			return
+		}
+	}
+	f.AddComment(&ast.CommentGroup{Doc: true, List: comments})
+}
+
+// attachFootComments attaches the comments that follow a mapping, are indented
+// at least up to the given column, and precede the next node of the enclosing
+// collection to the last field of the mapping.
+func (d *decoder) attachFootComments(column int, last ast.Node) {
+	comments := []*ast.Comment{}
+	for len(d.p.parser.comments) > 0 {
+		c := d.p.parser.comments[0]
+		if c.mark.index >= d.limit || c.mark.column < column {
+			break
+		}
+		comments = append(comments, &ast.Comment{
+			Slash: d.pos(c.mark),
+			Text:  "//" + c.text[1:],
+		})
+		d.p.parser.comments = d.p.parser.comments[1:]
+	}
+	if len(comments) > 0 {
+		last.AddComment(&ast.CommentGroup{
+			Position: 100,
+			List:     comments,
+		})
+	}
+}
+
 func (d *decoder) pos(m yaml_mark_t) token.Pos {
 	pos := d.p.info.Pos(m.index+1, token.NoRelPos)
 
@@ -406,12 +528,16 @@ func (d *decoder) ident(n *node, name string) *ast.Ident {
 func (d *decoder) document(n *node) ast.Expr {
 	if len(n.children) == 1 {
 		d.doc = n
+		d.limit = n.endPos.index
 		return d.unmarshal(n.children[0])
 	}
 	return &ast.BottomLit{} // TODO: more informatives
 }
 
 func (d *decoder) alias(n *node) ast.Expr {
+	if path, ok := d.refPath(n); ok {
+		return d.reference(n, path)
+	}
 	if d.aliases[n] {
 		// TODO this could actually be allowed in some circumstances.
 		d.p.failf(n.startPos.line, "anchor '%s' value contains itself", n.value)
@@ -627,12 +753,23 @@ func (d *decoder) sequence(n *node) ast.Expr {
 
 	noNewline := true
 	single := d.isOneLiner(n.startPos, n.endPos)
-	for _, c := range n.children {
+	limit := d.limit
+	for i, c := range n.children {
 		d.forceNewline = !single
+		if i+1 < len(n.children) {
+			d.limit = n.children[i+1].startPos.index
+		} else {
+			d.limit = limit
+		}
+		d.path = append(d.path, pathElem{index: i})
 		elem := d.unmarshal(c)
+		d.path = d.path[:len(d.path)-1]
+		d.attachDocComments(c.startPos, 0, elem)
+		d.attachTrailingComment(c.endPos, elem)
 		list.Elts = append(list.Elts, elem)
 		_, noNewline = elem.(*ast.StructLit)
 	}
+	d.limit = limit
 	if !single && !noNewline {
 		list.Rbrack = list.Rbrack.WithRel(token.Newline)
 	}
@@ -648,8 +785,18 @@ func (d *decoder) isOneLiner(start, end yaml_mark_t) bool {
 func (d *decoder) mapping(n *node) ast.Expr {
 	newline := d.forceNewline
 
+	isRoot := d.doc != nil && d.doc.children[0] == n
+	if !isRoot {
+		d.scopes = append(d.scopes, n)
+	}
 	structure := &ast.StructLit{}
-	d.insertMap(n, structure, false)
+	refs := d.insertMap(n, structure, false)
+	if !isRoot {
+		d.scopes = d.scopes[:len(d.scopes)-1]
+	}
+	if len(structure.Elts) > 0 && len(n.children) > 0 {
+		d.attachFootComments(n.children[0].startPos.column, structure.Elts[len(structure.Elts)-1])
+	}
 
 	// NOTE: we currently translate YAML without curly braces to CUE with
 	// curly braces, even for single elements. Removing the following line
@@ -664,14 +811,37 @@ func (d *decoder) mapping(n *node) ast.Expr {
 			structure.Rbrace = structure.Rbrace.WithRel(token.Blank)
 		}
 	}
-	return structure
+	if len(refs) == 0 {
+		return structure
+	}
+
+	// Unify the references to merged maps with the remaining fields.
+	expr := refs[0]
+	for _, r := range refs[1:] {
+		expr = &ast.BinaryExpr{X: expr, OpPos: token.Blank.Pos(), Op: token.AND, Y: r}
+	}
+	if len(structure.Elts) > 0 {
+		expr = &ast.BinaryExpr{X: expr, OpPos: token.Blank.Pos(), Op: token.AND, Y: structure}
+	}
+	return expr
 }
 
-func (d *decoder) insertMap(n *node, m *ast.StructLit, merge bool) {
+// insertMap adds the fields of the mapping n to m. Unless merge is set, it
+// returns references to the maps merged into n if these can be represented
+// as such, rather than adding their fields.
+func (d *decoder) insertMap(n *node, m *ast.StructLit, merge bool) (refs []ast.Expr) {
 	l := len(n.children)
+	limit := d.limit
+	defer func() { d.limit = limit }()
 outer:
 	for i := 0; i < l; i += 2 {
 		if isMerge(n.children[i]) {
+			if !merge {
+				if r := d.mergeRefs(n, n.children[i+1]); r != nil {
+					refs = append(refs, r...)
+					continue
+				}
+			}
 			merge = true
 			d.merge(n.children[i+1], m)
 			continue
@@ -690,6 +860,13 @@ outer:
 		field.Label = label
 		d.attachLineComment(n.children[i].endPos, 1, label)
 
+		if i+2 < l {
+			d.limit = n.children[i+2].startPos.index
+		} else {
+			d.limit = limit
+		}
+		d.path = append(d.path, pathElem{label: label})
+
 		if merge {
 			key := labelStr(label)
 			for _, decl := range m.Elts {
@@ -697,18 +874,28 @@ outer:
 				name, _ := ast.LabelName(f.Label)
 				if name == key {
 					f.Value = d.unmarshal(n.children[i+1])
+					for _, cg := range field.Comments() {
+						c := cg.List[0]
+						c.Slash = c.Slash.WithRel(token.Newline)
+						f.AddComment(cg)
+					}
+					d.path = d.path[:len(d.path)-1]
 					continue outer
 				}
 			}
 		}
 
+		head := d.headComments(n.children[i], n.children[i+1])
 		value := d.unmarshal(n.children[i+1])
+		d.path = d.path[:len(d.path)-1]
 		field.Value = value
+		d.addDocComments(field, head)
 		d.attachDocComments(n.children[i+1].startPos, 0, value)
 		d.attachLineComment(n.children[i+1].endPos, 10, value)
 
 		m.Elts = append(m.Elts, field)
 	}
+	return refs
 }
 
 func labelStr(l ast.Label) string {
@@ -760,3 +947,162 @@ func (d *decoder) merge(n *node, m *ast.StructLit) {
 func isMerge(n *node) bool {
 	return n.kind == scalarNode && n.value == "<<" && (n.implicit == true || n.tag == yaml_MERGE_TAG)
 }
+
+// refPath reports the path of the value anchored by the alias n if it can be
+// referred to from the node being decoded. The path must start with a field
+// of the root mapping that is not shadowed by a field of an enclosing mapping.
+func (d *decoder) refPath(n *node) ([]pathElem, bool) {
+	if !d.refs || d.aliases[n] {
+		return nil, false
+	}
+	path, ok := d.anchorPaths[n.alias]
+	if !ok || len(path) == 0 {
+		return nil, false
+	}
+	first, ok := path[0].label.(*ast.Ident)
+	if !ok || token.Lookup(first.Name).IsKeyword() {
+		return nil, false
+	}
+	if isPrefix(path, d.path) {
+		// The alias refers to a value that contains it.
+		return nil, false
+	}
+	for _, s := range d.scopes {
+		if hasKey(s, first.Name) {
+			return nil, false
+		}
+	}
+	return path, true
+}
+
+func isPrefix(a, b []pathElem) bool {
+	if len(a) > len(b) {
+		return false
+	}
+	for i, e := range a {
+		if labelStr(e.label) != labelStr(b[i].label) || e.index != b[i].index {
+			return false
+		}
+	}
+	return true
+}
+
+// reference returns a reference to the value at the given path for the
+// alias n.
+func (d *decoder) reference(n *node, path []pathElem) ast.Expr {
+	var expr ast.Expr = d.ident(n, path[0].label.(*ast.Ident).Name)
+	for _, e := range path[1:] {
+		switch x := e.label.(type) {
+		case nil:
+			expr = &ast.IndexExpr{X: expr, Index: &ast.BasicLit{
+				Kind:  token.INT,
+				Value: strconv.Itoa(e.index),
+			}}
+		case *ast.Ident:
+			expr = &ast.SelectorExpr{X: expr, Sel: ast.NewIdent(x.Name)}
+		default:
+			expr = &ast.IndexExpr{X: expr, Index: &ast.BasicLit{
+				Kind:  token.STRING,
+				Value: strconv.Quote(labelStr(x)),
+			}}
+		}
+	}
+	return expr
+}
+
+// mergeRefs returns references to the maps merged into the mapping n by a
+// merge key with value v, or nil if these cannot be represented as
+// references. As CUE unifies values, rather than overriding them, the merged
+// maps may not have keys in common with each other or with n.
+func (d *decoder) mergeRefs(n, v *node) []ast.Expr {
+	if !d.refs {
+		return nil
+	}
+	aliases := []*node{v}
+	if v.kind == sequenceNode {
+		aliases = v.children
+	}
+	seen := map[string]bool{}
+	for i := 0; i < len(n.children); i += 2 {
+		if isMerge(n.children[i]) {
+			if n.children[i+1] != v {
+				return nil
+			}
+			continue
+		}
+		seen[n.children[i].value] = true
+	}
+	paths := make([][]pathElem, len(aliases))
+	for i, a := range aliases {
+		if a.kind != aliasNode || a.alias.kind != mappingNode {
+			return nil
+		}
+		path, ok := d.refPath(a)
+		if !ok {
+			return nil
+		}
+		paths[i] = path
+		for _, k := range mapKeys(a.alias) {
+			if seen[k] {
+				return nil
+			}
+			seen[k] = true
+		}
+	}
+	refs := make([]ast.Expr, len(aliases))
+	for i, a := range aliases {
+		refs[i] = d.reference(a, paths[i])
+		// Keep the references on the line of the field.
+		x := refs[i]
+		for {
+			switch y := x.(type) {
+			case *ast.SelectorExpr:
+				x = y.X
+				continue
+			case *ast.IndexExpr:
+				x = y.X
+				continue
+			case *ast.Ident:
+				y.NamePos = y.NamePos.WithRel(token.Blank)
+			}
+			break
+		}
+	}
+	return refs
+}
+
+// hasKey reports whether the mapping n, including the maps merged into it,
+// has a key with the given name.
+func hasKey(n *node, name string) bool {
+	for _, k := range mapKeys(n) {
+		if k == name {
+			return true
+		}
+	}
+	return false
+}
+
+// mapKeys returns the keys of the mapping n, including those of the maps
+// merged into it.
+func mapKeys(n *node) (keys []string) {
+	for i := 0; i < len(n.children); i += 2 {
+		if !isMerge(n.children[i]) {
+			keys = append(keys, n.children[i].value)
+			continue
+		}
+		v := n.children[i+1]
+		merged := []*node{v}
+		if v.kind == sequenceNode {
+			merged = v.children
+		}
+		for _, m := range merged {
+			if m.kind == aliasNode {
+				m = m.alias
+			}
+			if m.kind == mappingNode {
+				keys = append(keys, mapKeys(m)...)
+			}
+		}
+	}
+	return keys
+}
@@ -192,7 +192,8 @@ var unmarshalTests = []struct {
 	// Literal block scalar
 	{
 		"scalar: | # Comment\n\n literal\n\n \ttext\n\n",
-		`scalar: """
+		`// Comment
+scalar: """
 
 		literal
 
@@ -204,7 +205,8 @@ var unmarshalTests = []struct {
 	// Folded block scalar
 	{
 		"scalar: > # Comment\n\n folded\n line\n \n next\n line\n  * one\n  * two\n\n last\n line\n\n",
-		`scalar: """
+		`// Comment
+scalar: """
 
 		folded line
 		next line
@@ -847,16 +849,30 @@ func TestDecoderErrors(t *testing.T) {
 }
 
 func TestFiles(t *testing.T) {
-	files := []string{"merge"}
-	for _, test := range files {
-		t.Run(test, func(t *testing.T) {
-			testname := fmt.Sprintf("testdata/%s.test", test)
-			filename := fmt.Sprintf("testdata/%s.out", test)
-			mergeTests, err := ioutil.ReadFile(testname)
+	testCases := []struct {
+		name  string
+		input string
+		refs  bool
+	}{
+		{name: "merge", input: "merge"},
+		{name: "merge_refs", input: "merge", refs: true},
+		{name: "comments", input: "comments"},
+		{name: "anchors", input: "anchors", refs: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testname := fmt.Sprintf("testdata/%s.test", tc.input)
+			filename := fmt.Sprintf("testdata/%s.out", tc.name)
+			data, err := ioutil.ReadFile(testname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dec, err := yaml.NewDecoder("test.yaml", data)
 			if err != nil {
 				t.Fatal(err)
 			}
-			expr, err := yaml.Unmarshal("test.yaml", mergeTests)
+			dec.SetAnchorReferences(tc.refs)
+			expr, err := dec.Decode()
 			if err != nil {
 				t.Fatal(err)
 			}
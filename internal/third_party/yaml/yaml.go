@@ -87,6 +87,7 @@ func Unmarshal(filename string, in []byte) (expr ast.Expr, err error) {
 // A Decorder reads and decodes YAML values from an input stream.
 type Decoder struct {
 	strict bool
+	refs   bool
 	parser *parser
 }
 
@@ -102,6 +103,14 @@ func NewDecoder(filename string, src interface{}) (*Decoder, error) {
 	return &Decoder{parser: d}, nil
 }
 
+// SetAnchorReferences determines whether aliases are converted to
+// references to the values of their anchors instead of to copies of these
+// values. Where a reference cannot be expressed, such as for a merge key with
+// overridden fields, the value is still copied.
+func (dec *Decoder) SetAnchorReferences(enable bool) {
+	dec.refs = enable
+}
+
 // Decode reads the next YAML-encoded value from its input and stores it in the
 // value pointed to by v. It returns io.EOF if there are no more value in the
 // stream.
@@ -110,6 +119,7 @@ func NewDecoder(filename string, src interface{}) (*Decoder, error) {
 // into a Go value.
 func (dec *Decoder) Decode() (expr ast.Expr, err error) {
 	d := newDecoder(dec.parser)
+	d.refs = dec.refs
 	defer handleErr(&err)
 	node := dec.parser.parse()
 	if node == nil {
@@ -0,0 +1,47 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toml
+
+import (
+	"encoding/json"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/internal/encoding/toml"
+)
+
+// Marshal returns the TOML encoding of v, which must be a struct.
+func Marshal(v cue.Value) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	expr, err := parser.ParseExpr("", b)
+	if err != nil {
+		return "", err
+	}
+	b, err = toml.Marshal(expr)
+	return string(b), err
+}
+
+// Unmarshal parses the TOML to a CUE expression.
+func Unmarshal(data []byte) (ast.Expr, error) {
+	f, err := toml.Parse("", data)
+	if err != nil {
+		return nil, err
+	}
+	return &ast.StructLit{Elts: f.Decls}, nil
+}
@@ -24,7 +24,7 @@ import (
 
 // MinItems reports whether a has at least n items.
 func MinItems(a []cue.Value, n int) bool {
-	return len(a) <= n
+	return len(a) >= n
 }
 
 // MaxItems reports whether a has at most n items.
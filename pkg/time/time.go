@@ -0,0 +1,167 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package time provides functionality for representing and displaying time.
+//
+// Times are represented as RFC 3339 strings and durations as strings such as
+// "1h30m" or as an integer number of nanoseconds. Functions that return a time
+// return it in UTC. Times should not be compared as strings, as times in
+// different time zones or with fractional seconds of different lengths do
+// not sort in chronological order. Use Before and After instead.
+//
+// Some of the functions in this package are specifically intended as field
+// constraints. For instance, Format as used in this CUE program
+//
+//	import "time"
+//
+//	date:    time.Format("2006-01-02")
+//	timeout: time.Duration()
+//
+// specifies that date must be a date in the given layout and that timeout
+// must be a valid duration. To format a time, use FormatString.
+package time
+
+import "time"
+
+// These are predefined layouts for use in Format, FormatString, and Parse.
+const (
+	ANSIC       = "Mon Jan _2 15:04:05 2006"
+	UnixDate    = "Mon Jan _2 15:04:05 MST 2006"
+	RubyDate    = "Mon Jan 02 15:04:05 -0700 2006"
+	RFC822      = "02 Jan 06 15:04 MST"
+	RFC822Z     = "02 Jan 06 15:04 -0700" // RFC822 with numeric zone
+	RFC850      = "Monday, 02-Jan-06 15:04:05 MST"
+	RFC1123     = "Mon, 02 Jan 2006 15:04:05 MST"
+	RFC1123Z    = "Mon, 02 Jan 2006 15:04:05 -0700" // RFC1123 with numeric zone
+	RFC3339     = "2006-01-02T15:04:05Z07:00"
+	RFC3339Nano = "2006-01-02T15:04:05.999999999Z07:00"
+	Kitchen     = "3:04PM"
+)
+
+// Common durations, in nanoseconds.
+//
+// To count the number of units in a duration, divide:
+//
+//	seconds: time.ParseDuration("1m30s") div time.Second // 90
+//
+// To convert an integer number of units to a duration, multiply:
+//
+//	seconds: 10
+//	timeout: seconds * time.Second // 10000000000
+const (
+	Nanosecond  = 1
+	Microsecond = 1000
+	Millisecond = 1000000
+	Second      = 1000000000
+	Minute      = 60000000000
+	Hour        = 3600000000000
+)
+
+// Duration validates a duration string, such as "300ms", "-1.5h" or "2h45m".
+//
+// A duration string is a possibly signed sequence of decimal numbers, each
+// with optional fraction and a unit suffix. Valid time units are "ns", "us"
+// (or "µs"), "ms", "s", "m", "h".
+func Duration(s string) (bool, error) {
+	if _, err := time.ParseDuration(s); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ParseDuration returns the number of nanoseconds of a duration string, such
+// as "300ms", "-1.5h" or "2h45m". See Duration for the format of duration
+// strings.
+func ParseDuration(s string) (int64, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	return int64(d), nil
+}
+
+// FormatDuration returns a duration string, such as "72h3m0.5s", for the
+// given number of nanoseconds.
+func FormatDuration(d int64) string {
+	return time.Duration(d).String()
+}
+
+// Format validates that a string is a time in the given layout.
+//
+// The layout defines the format by showing how the reference time,
+//
+//	Mon Jan 2 15:04:05 -0700 MST 2006
+//
+// would be represented. See the documentation of Go's time package for
+// details.
+func Format(value, layout string) (bool, error) {
+	if _, err := time.Parse(layout, value); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// FormatString returns a textual representation of the RFC 3339 time value,
+// formatted according to layout. See Format for a description of layouts.
+func FormatString(layout, value string) (string, error) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return "", err
+	}
+	return t.Format(layout), nil
+}
+
+// Parse parses a time formatted according to layout and returns it as an
+// RFC 3339 time in UTC. See Format for a description of layouts.
+//
+// In the absence of a time zone indicator, Parse returns a time in UTC.
+func Parse(layout, value string) (string, error) {
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return "", err
+	}
+	return t.UTC().Format(time.RFC3339Nano), nil
+}
+
+// Unix returns the RFC 3339 time in UTC corresponding to the given Unix time,
+// sec seconds and nsec nanoseconds since January 1, 1970 UTC.
+func Unix(sec int64, nsec int64) string {
+	return time.Unix(sec, nsec).UTC().Format(time.RFC3339Nano)
+}
+
+// Before validates that an RFC 3339 time is before the time t.
+func Before(value, t string) (bool, error) {
+	a, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return false, err
+	}
+	b, err := time.Parse(time.RFC3339Nano, t)
+	if err != nil {
+		return false, err
+	}
+	return a.Before(b), nil
+}
+
+// After validates that an RFC 3339 time is after the time t.
+func After(value, t string) (bool, error) {
+	a, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return false, err
+	}
+	b, err := time.Parse(time.RFC3339Nano, t)
+	if err != nil {
+		return false, err
+	}
+	return a.After(b), nil
+}
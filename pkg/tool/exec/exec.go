@@ -17,10 +17,14 @@ package exec
 //go:generate go run gen.go
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"os/exec"
 	"strings"
+	"syscall"
+	"time"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/internal/task"
@@ -76,34 +80,36 @@ func (c *execCmd) Run(ctx *task.Context, v cue.Value) (res interface{}, err erro
 		}
 	}
 
-	cmd := exec.CommandContext(ctx.Context, bin, args...)
+	cmd := exec.Command(bin, args...)
 
 	if v := v.Lookup("stdin"); v.IsValid() {
 		if cmd.Stdin, err = v.Reader(); err != nil {
 			return nil, fmt.Errorf("cue: %v", err)
 		}
 	}
+	var stdout, stderr bytes.Buffer
 	captureOut := v.Lookup("stdout").Exists()
-	if !captureOut {
+	if captureOut {
+		cmd.Stdout = &stdout
+	} else {
 		cmd.Stdout = ctx.Stdout
 	}
 	captureErr := v.Lookup("stderr").Exists()
-	if !captureErr {
+	if captureErr {
+		cmd.Stderr = &stderr
+	} else {
 		cmd.Stderr = ctx.Stderr
 	}
 
 	update := map[string]interface{}{}
+	err = run(ctx.Context, cmd)
 	if captureOut {
-		var stdout []byte
-		stdout, err = cmd.Output()
-		update["stdout"] = string(stdout)
-	} else {
-		err = cmd.Run()
+		update["stdout"] = stdout.String()
 	}
 	update["success"] = err == nil
 	if err != nil {
 		if exit := (*exec.ExitError)(nil); xerrors.As(err, &exit) && captureErr {
-			update["stderr"] = string(exit.Stderr)
+			update["stderr"] = stderr.String()
 		} else {
 			update = nil
 		}
@@ -111,3 +117,42 @@ func (c *execCmd) Run(ctx *task.Context, v cue.Value) (res interface{}, err erro
 	}
 	return update, err
 }
+
+// gracePeriod is the time a process is given to terminate after it has been
+// sent a termination signal before it is killed. It is a variable for
+// testing.
+var gracePeriod = 5 * time.Second
+
+// run runs cmd until completion. If ctx is canceled before the command
+// completes, the process is sent a termination signal and is killed if it
+// does not terminate within the grace period.
+func run(ctx context.Context, cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+
+	// Signals other than kill are not supported on all platforms.
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		_ = cmd.Process.Kill()
+	}
+
+	timer := time.NewTimer(gracePeriod)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		_ = cmd.Process.Kill()
+	}
+	return <-done
+}
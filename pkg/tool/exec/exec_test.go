@@ -0,0 +1,85 @@
+// Copyright 2019 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"bufio"
+	"context"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunCancel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("termination signals are not supported on Windows")
+	}
+	defer func(d time.Duration) { gracePeriod = d }(gracePeriod)
+	gracePeriod = 500 * time.Millisecond
+
+	testCases := []struct {
+		name   string
+		script string
+		want   string
+		min    time.Duration
+	}{{
+		// The process exits upon receiving SIGTERM.
+		name:   "terminate",
+		script: `trap "exit 3" TERM; echo ready; while :; do sleep 0.1; done`,
+		want:   "exit status 3",
+	}, {
+		// The process ignores SIGTERM and is killed after the grace period.
+		name:   "kill",
+		script: `trap "" TERM; echo ready; while :; do sleep 0.1; done`,
+		want:   "signal: killed",
+		min:    gracePeriod,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			cmd := exec.Command("sh", "-c", tc.script)
+			out, err := cmd.StdoutPipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var canceled time.Time
+			go func() {
+				// Cancel once the signal handler is installed.
+				_, _ = bufio.NewReader(out).ReadString('\n')
+				canceled = time.Now()
+				cancel()
+			}()
+
+			done := make(chan error, 1)
+			go func() { done <- run(ctx, cmd) }()
+
+			select {
+			case err = <-done:
+			case <-time.After(10 * time.Second):
+				t.Fatal("command did not terminate")
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("got error %v; want %q", err, tc.want)
+			}
+			if d := time.Since(canceled); d < tc.min {
+				t.Errorf("terminated after %v; want at least %v", d, tc.min)
+			}
+		})
+	}
+}